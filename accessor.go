@@ -0,0 +1,265 @@
+package ruler
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+/**
+  按类型缓存的取值计划：只解析子规则引用到的key，避免每次Fit都把整个结构体转换成map
+  结果与 Adapter.Map + pluck 完全一致，无法静态解析的部分（接口、flatten、同名字段等）退回到转换后再pluck
+  计划记录生成时子规则的key，构造后修改或增删子规则时重新生成；缓存在首次匹配结构体时创建，字面量构造的Rules同样缓存
+*/
+
+// planKey 取值计划的缓存键
+type planKey struct {
+	adapter *StructAdapter
+	typ     reflect.Type
+}
+
+// accessPlan 某个结构体类型下，Rules中每条子规则的取值器，下标与Rules.Rules一致
+type accessPlan struct {
+	keys      []string // 生成计划时子规则的key，与当前子规则不一致时重新生成
+	geo       []bool   // 生成计划时子规则是否为地理位置算符，决定key是否拆为纬度、经度
+	accessors []*keyAccessor
+}
+
+// keyAccessor 单个key的取值器
+type keyAccessor struct {
+	adapter *StructAdapter
+	steps   []*fieldStep   // 从根结构体开始可静态解析的字段
+	rest    []string       // 剩余路径，运行时转换后继续pluck
+	invalid bool           // key为空或语法错误，总是取不到值
+	latLon  []*keyAccessor // 地理位置算符的纬度、经度两个key
+}
+
+// fieldStep 一级字段的取值方式
+type fieldStep struct {
+	index []int // 字段下标路径，可能穿过嵌入结构体
+	field *structField
+}
+
+// planOf 取得o的类型对应的取值计划，o不是结构体时返回nil
+func (rs *Rules) planOf(adapter *StructAdapter, o interface{}) (*accessPlan, reflect.Value) {
+	if adapter == nil {
+		adapter = defaultAdapter
+	}
+	v := reflect.ValueOf(o)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, v
+	}
+	key := planKey{adapter: adapter, typ: v.Type()}
+	plans := rs.planCache()
+	if cached, ok := plans.Load(key); ok && cached.(*accessPlan).fits(rs.Rules) {
+		return cached.(*accessPlan), v
+	}
+	plan := &accessPlan{keys: make([]string, len(rs.Rules)), geo: make([]bool, len(rs.Rules)), accessors: make([]*keyAccessor, len(rs.Rules))}
+	for index, rule := range rs.Rules {
+		plan.keys[index], plan.geo[index] = rule.Key, isGeoOp(rule.Op)
+		if keys := rule.keyPaths(); len(keys) == 2 {
+			plan.accessors[index] = &keyAccessor{adapter: adapter, latLon: []*keyAccessor{
+				adapter.compileAccessor(v.Type(), keys[0]), adapter.compileAccessor(v.Type(), keys[1]),
+			}}
+			continue
+		}
+		plan.accessors[index] = adapter.compileAccessor(v.Type(), rule.Key)
+	}
+	plans.Store(key, plan)
+	return plan, v
+}
+
+// planCache 取值计划的缓存，首次使用时创建，并发创建时只保留一个
+func (rs *Rules) planCache() *sync.Map {
+	if plans, ok := rs.plans.Load().(*sync.Map); ok {
+		return plans
+	}
+	rs.plans.CompareAndSwap(nil, new(sync.Map))
+	return rs.plans.Load().(*sync.Map)
+}
+
+// fits 计划是否与当前的子规则一致，构造后修改、增删子规则时计划失效
+func (plan *accessPlan) fits(rules []*Rule) bool {
+	if len(plan.keys) != len(rules) {
+		return false
+	}
+	for index, rule := range rules {
+		if plan.keys[index] != rule.Key || plan.geo[index] != isGeoOp(rule.Op) {
+			return false
+		}
+	}
+	return true
+}
+
+// compileAccessor 静态解析key在类型t下能确定的字段路径
+func (a *StructAdapter) compileAccessor(t reflect.Type, key string) *keyAccessor {
+	acc := &keyAccessor{adapter: a}
+	paths, err := parseKeyPath(key)
+	if err != nil {
+		acc.invalid = true
+		return acc
+	}
+	for index, step := range paths {
+		fs := a.resolveField(t, step)
+		if fs == nil {
+			acc.rest = paths[index:]
+			return acc
+		}
+		acc.steps = append(acc.steps, fs)
+		// 下一层仍须是可静态解析的结构体
+		next, ok := a.nextStructType(t.FieldByIndex(fs.index).Type, fs.field)
+		if !ok {
+			acc.rest = paths[index+1:]
+			return acc
+		}
+		t = next
+	}
+	return acc
+}
+
+// resolveField 在结构体类型t中查找键名为name的唯一字段，存在歧义或无法静态确定时返回nil
+func (a *StructAdapter) resolveField(t reflect.Type, name string) *fieldStep {
+	var found []*fieldStep
+	var embedded []*structField
+	for _, field := range a.fieldsOf(t) {
+		if a.legacy && field.has("flatten") {
+			// flatten的字段会覆盖同级键名，交给运行时
+			return nil
+		}
+		if field.embedded {
+			embedded = append(embedded, field)
+			continue
+		}
+		if field.name == name {
+			found = append(found, &fieldStep{index: []int{field.index}, field: field})
+		}
+	}
+	for _, field := range embedded {
+		ft := t.Field(field.index).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sub := a.resolveField(ft, name); sub != nil {
+			found = append(found, &fieldStep{index: append([]int{field.index}, sub.index...), field: sub.field})
+		} else if a.hasName(ft, name) {
+			return nil
+		}
+	}
+	if len(found) != 1 {
+		return nil
+	}
+	return found[0]
+}
+
+// hasName 嵌入结构体中是否存在该键名（可能无法静态解析）
+func (a *StructAdapter) hasName(t reflect.Type, name string) bool {
+	for _, field := range a.fieldsOf(t) {
+		if field.name == name || field.embedded || a.legacy && field.has("flatten") {
+			return true
+		}
+	}
+	return false
+}
+
+// nextStructType 字段转换后若是map，返回可继续静态解析的结构体类型
+func (a *StructAdapter) nextStructType(t reflect.Type, field *structField) (reflect.Type, bool) {
+	if a.legacy && (field.has("string") || field.has("omitnested")) {
+		return nil, false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if a.legacy && t.Kind() == reflect.Ptr {
+			return nil, false
+		}
+	}
+	for !a.legacy && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct
+}
+
+// get 从根结构体取值，同时返回key是否存在
+func (acc *keyAccessor) get(root reflect.Value) (interface{}, bool) {
+	a := acc.adapter
+	if acc.invalid {
+		return nil, false
+	}
+	if len(acc.latLon) == 2 {
+		lat, latOK := acc.latLon[0].get(root)
+		lon, lonOK := acc.latLon[1].get(root)
+		return joinLatLon(lat, latOK, lon, lonOK)
+	}
+	if len(acc.steps) == 0 {
+		return lookupPath(acc.rest, a.Map(root.Interface()))
+	}
+	v := root
+	for index, fs := range acc.steps {
+		fv, ok := fieldByIndex(v, fs.index)
+		if !ok {
+			return nil, false
+		}
+		if fs.field.has("omitempty") && fv.IsZero() {
+			return nil, false
+		}
+		if index < len(acc.steps)-1 {
+			if v, ok = derefStruct(fv); !ok {
+				return nil, false
+			}
+			continue
+		}
+		val := a.fieldValue(fv, fs.field)
+		if len(acc.rest) == 0 {
+			return val, true
+		}
+		// 剩余路径交给转换后的值
+		return lookupPath(acc.rest, val)
+	}
+	return nil, false
+}
+
+// fieldValue 字段值按适配器规则转换，与Map中该字段的值一致
+func (a *StructAdapter) fieldValue(fv reflect.Value, field *structField) interface{} {
+	if !a.legacy {
+		return a.convert(fv)
+	}
+	if field.has("string") {
+		if s, ok := fv.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+		return nil
+	}
+	if field.has("omitnested") {
+		return fv.Interface()
+	}
+	return a.convert(fv)
+}
+
+// fieldByIndex 按下标路径取字段，途经的嵌入指针为nil时返回false
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// derefStruct 取指针指向的结构体，nil时返回false
+func derefStruct(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v, v.Kind() == reflect.Struct
+}
@@ -0,0 +1,203 @@
+package ruler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type accessorExams struct {
+	Math   int `json:"math"`
+	Physic int `json:"physic,omitempty"`
+}
+
+type accessorBase struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type accessorStudent struct {
+	accessorBase
+	*accessorExams `json:"exams"`
+	Name           string                   `json:"nick" structs:"nick"`
+	Score          *accessorExams           `json:"score"`
+	Empty          *accessorExams           `json:"empty"`
+	History        map[string]accessorExams `json:"history"`
+	Extra          interface{}              `json:"extra"`
+	Born           time.Time                `json:"born"`
+	Wait           time.Duration            `structs:"wait,string"`
+	Raw            accessorExams            `structs:"raw,omitnested"`
+	Flat           accessorBase             `structs:",flatten"`
+	Skip           string                   `json:"-" structs:"-"`
+	Tags           []string                 `json:"tags,omitempty" structs:"tags,omitempty"`
+}
+
+func newAccessorStudent() *accessorStudent {
+	return &accessorStudent{
+		accessorBase:  accessorBase{ID: 7, Name: "base"},
+		accessorExams: &accessorExams{Math: 60},
+		Name:          "chris",
+		Score:         &accessorExams{Math: 90, Physic: 91},
+		History:       map[string]accessorExams{"2019": {Math: 70}},
+		Extra:         &accessorExams{Math: 3},
+		Born:          time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		Wait:          time.Second,
+		Raw:           accessorExams{Math: 4},
+		Flat:          accessorBase{ID: 8},
+		Skip:          "skip",
+	}
+}
+
+func TestKeyAccessor_Get(t *testing.T) {
+	keys := []string{
+		"id", "name", "nick", "exams", "exams.math", "math", "score", "score.math", "score.physic",
+		"empty", "empty.math", "history", "history.2019", "history.2019.math", "extra.math", "born", "born.x",
+		"accessorBase", "accessorBase.ID", "accessorExams.Math", "Name", "ID", "Score.Math", "Empty.Math",
+		"History.2019.Math", "Extra.Math", "Born", "wait", "raw", "raw.Math", "Skip", "tags", "missing", "missing.deep",
+	}
+	s := newAccessorStudent()
+	for _, adapter := range []*StructAdapter{nil, NewStructAdapter("json")} {
+		m := adapter.Map(s)
+		rules := make([]*Rule, 0, len(keys))
+		for _, key := range keys {
+			rules = append(rules, &Rule{Op: "nempty", Key: key})
+		}
+		rs := newRulesWithArray(rules)
+		plan, root := rs.planOf(adapter, s)
+		assert.NotNil(t, plan)
+		for index, key := range keys {
+			expect, expectExists := lookup(key, m)
+			v, exists := plan.accessors[index].get(root)
+			assert.Equal(t, expect, v, fmt.Sprintf("key: %s", key))
+			assert.Equal(t, expectExists, exists, fmt.Sprintf("key: %s", key))
+		}
+	}
+}
+
+func TestRules_FitWithPlan(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": ">=", "key": "Score.Math", "val": 90, "id": 1, "msg": "Math not so well"},
+	{"op": "=", "key": "nick", "val": "chris", "id": 2, "msg": "Name not match"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "1 and 2")
+	if err != nil {
+		t.Error(err)
+	}
+	fit, _, values := rs.FitAskVal(newAccessorStudent())
+	assert.True(t, fit)
+	assert.Equal(t, map[int]interface{}{1: 90, 2: "chris"}, values)
+
+	// cached plan serves another object of the same type
+	other := newAccessorStudent()
+	other.Score = nil
+	fit, msg := rs.Fit(other)
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "Math not so well"}, msg)
+}
+
+type benchmarkOrder struct {
+	ID       int
+	Customer struct {
+		Name    string
+		Level   int
+		Address struct {
+			City   string
+			Street string
+			Zip    string
+		}
+	}
+	Items    []accessorExams
+	Extras   map[string]accessorExams
+	Amount   float64
+	Currency string
+	Remark   string
+	Created  time.Time
+	Tags     []string
+}
+
+func newBenchmarkOrder() *benchmarkOrder {
+	o := &benchmarkOrder{ID: 1, Amount: 99.5, Currency: "CNY", Created: time.Now(), Tags: []string{"a", "b"}}
+	o.Customer.Name = "chris"
+	o.Customer.Level = 3
+	o.Customer.Address.City = "Beijing"
+	o.Extras = make(map[string]accessorExams)
+	for i := 0; i < 20; i++ {
+		o.Items = append(o.Items, accessorExams{Math: i, Physic: i})
+		o.Extras[fmt.Sprint(i)] = accessorExams{Math: i}
+	}
+	return o
+}
+
+func benchmarkOrderRules(b *testing.B) *Rules {
+	jsonRules := []byte(`[
+	{"op": ">=", "key": "Customer.Level", "val": 3, "id": 1},
+	{"op": "=", "key": "Customer.Address.City", "val": "Beijing", "id": 2},
+	{"op": ">", "key": "Amount", "val": 10, "id": 3}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return rs
+}
+
+func BenchmarkRules_Fit(b *testing.B) {
+	rs := benchmarkOrderRules(b)
+	o := newBenchmarkOrder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rs.Fit(o)
+	}
+}
+
+func BenchmarkRules_FitWithWholeMap(b *testing.B) {
+	rs := benchmarkOrderRules(b)
+	o := newBenchmarkOrder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rs.FitWithMap(rs.Adapter.Map(o))
+	}
+}
+
+func TestRules_FitWithPlanAfterChange(t *testing.T) {
+	type S struct {
+		A, B int
+		Pos  string
+	}
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": 1, "id": 1}]`), "")
+	assert.Nil(t, err)
+	fit, _ := rs.Fit(S{A: 1, B: 2})
+	assert.True(t, fit)
+
+	// 构造后修改子规则的key，取值计划随之更新
+	rs.Rules[0].Key = "B"
+	fit, _ = rs.Fit(S{A: 1, B: 2})
+	assert.False(t, fit)
+
+	// 新增子规则
+	rs.Rules = append(rs.Rules, &Rule{Op: "=", Key: "A", Val: 1, ID: 2})
+	fit, tips := rs.Fit(S{A: 1, B: 1})
+	assert.True(t, fit)
+	assert.Len(t, tips, 2)
+	fit, _ = rs.Fit(S{A: 2, B: 1})
+	assert.False(t, fit)
+
+	// 不经构造方法的Rules首次匹配时创建缓存，之后复用取值计划
+	literal := &Rules{Rules: []*Rule{{Op: "=", Key: "B", Val: 2, ID: 1}}}
+	fit, _ = literal.Fit(S{B: 2})
+	assert.True(t, fit)
+	plan, _ := literal.planOf(nil, S{})
+	cached, _ := literal.planOf(nil, &S{B: 1})
+	assert.True(t, plan == cached)
+	fit, _ = literal.Fit(S{B: 3})
+	assert.False(t, fit)
+
+	// 复制Rules不复制锁
+	copied := *rs
+	fit, _ = copied.Fit(S{A: 1, B: 1})
+	assert.True(t, fit)
+}
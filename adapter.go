@@ -0,0 +1,262 @@
+package ruler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// StructAdapter 结构体转map的适配器，决定子规则key如何对应结构体字段
+type StructAdapter struct {
+	TagName string // 取键名的tag，如"json"；为空时直接使用字段名
+	legacy  bool   // 兼容structs.Map：匿名结构体按字段名嵌套，支持flatten、omitnested、string选项
+}
+
+// defaultAdapter Rules未指定Adapter时使用，行为与structs.Map一致
+var defaultAdapter = &StructAdapter{TagName: "structs", legacy: true}
+
+// NewStructAdapter 构造按tagName取键名的适配器：匿名嵌入结构体平铺到外层，指针和接口取其指向的值，tag为"-"的字段忽略
+func NewStructAdapter(tagName string) *StructAdapter {
+	return &StructAdapter{TagName: tagName}
+}
+
+// Map 结构体转换为map，嵌套的结构体（包括map和切片中的）也会被转换
+func (a *StructAdapter) Map(o interface{}) map[string]interface{} {
+	if a == nil {
+		a = defaultAdapter
+	}
+	v := reflect.ValueOf(o)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Map {
+		if m, ok := a.convert(v).(map[string]interface{}); ok {
+			return m
+		}
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	out := make(map[string]interface{})
+	a.fillMap(v, out)
+	return out
+}
+
+// structField 结构体字段在适配器下的键名与选项
+type structField struct {
+	index    int
+	name     string
+	embedded bool
+	opts     []string
+}
+
+func (f *structField) has(opt string) bool {
+	for _, o := range f.opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldsOf 按适配器规则列出结构体类型的可导出字段
+func (a *StructAdapter) fieldsOf(t reflect.Type) []*structField {
+	var fields []*structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get(a.TagName)
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		// 未导出字段无法取值，但与encoding/json一致，未导出的嵌入结构体中可导出的字段仍会平铺
+		if sf.PkgPath != EmptyStr && (a.legacy || !sf.Anonymous || parts[0] != EmptyStr || !isStructType(sf.Type)) {
+			continue
+		}
+		field := &structField{index: i, name: sf.Name, opts: parts[1:]}
+		if parts[0] != EmptyStr {
+			field.name = parts[0]
+		}
+		if !a.legacy && sf.Anonymous && parts[0] == EmptyStr {
+			field.embedded = isStructType(sf.Type)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// isStructType 结构体或结构体指针
+func isStructType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func (a *StructAdapter) fillMap(v reflect.Value, out map[string]interface{}) {
+	fields := a.fieldsOf(v.Type())
+	if !a.legacy {
+		// 嵌入结构体的字段先填，外层同名字段覆盖之
+		for _, field := range fields {
+			if !field.embedded {
+				continue
+			}
+			fv := v.Field(field.index)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			a.fillMap(fv, out)
+		}
+	}
+	for _, field := range fields {
+		if field.embedded {
+			continue
+		}
+		fv := v.Field(field.index)
+		if field.has("omitempty") && fv.IsZero() {
+			continue
+		}
+		if !a.legacy {
+			out[field.name] = a.convert(fv)
+			continue
+		}
+		a.fillLegacy(fv, field, out)
+	}
+}
+
+// fillLegacy 与structs.Map的单字段处理保持一致
+func (a *StructAdapter) fillLegacy(fv reflect.Value, field *structField, out map[string]interface{}) {
+	if field.has("string") {
+		if s, ok := fv.Interface().(fmt.Stringer); ok {
+			out[field.name] = s.String()
+		}
+		return
+	}
+	if field.has("omitnested") {
+		out[field.name] = fv.Interface()
+		return
+	}
+	finalVal := a.convert(fv)
+	if sub, ok := finalVal.(map[string]interface{}); ok && field.has("flatten") {
+		for k := range sub {
+			out[k] = sub[k]
+		}
+		return
+	}
+	out[field.name] = finalVal
+}
+
+// convert 把单个值转换为规则可读取的形式
+func (a *StructAdapter) convert(v reflect.Value) interface{} {
+	if a.legacy {
+		return a.convertLegacy(v)
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		a.fillMap(v, m)
+		// 没有可导出字段的结构体保持原值，如time.Time
+		if len(m) == 0 {
+			return v.Interface()
+		}
+		return m
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.IsNil() {
+			return v.Interface()
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = a.convert(iter.Value())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if !isNestedKind(v.Type().Elem()) {
+			return v.Interface()
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list[i] = a.convert(v.Index(i))
+		}
+		return list
+	default:
+		return v.Interface()
+	}
+}
+
+// isNestedKind 元素类型是否可能包含需要转换的结构体
+func isNestedKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Interface, reflect.Slice, reflect.Array:
+		return true
+	case reflect.Ptr:
+		return isNestedKind(t.Elem())
+	default:
+		return false
+	}
+}
+
+// convertLegacy 与structs.Map的嵌套转换保持一致
+func (a *StructAdapter) convertLegacy(val reflect.Value) interface{} {
+	v := reflect.ValueOf(val.Interface())
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		a.fillMap(v, m)
+		if len(m) == 0 {
+			return val.Interface()
+		}
+		return m
+	case reflect.Map:
+		mapElem := val.Type()
+		switch val.Type().Kind() {
+		case reflect.Ptr, reflect.Array, reflect.Map, reflect.Slice, reflect.Chan:
+			mapElem = val.Type().Elem()
+			if mapElem.Kind() == reflect.Ptr {
+				mapElem = mapElem.Elem()
+			}
+		}
+		// 只展开元素为结构体的map
+		if mapElem.Kind() == reflect.Struct ||
+			(mapElem.Kind() == reflect.Slice && mapElem.Elem().Kind() == reflect.Struct) {
+			m := make(map[string]interface{}, val.Len())
+			for _, k := range val.MapKeys() {
+				m[k.String()] = a.convertLegacy(val.MapIndex(k))
+			}
+			return m
+		}
+		return val.Interface()
+	case reflect.Slice, reflect.Array:
+		if val.Type().Kind() == reflect.Interface {
+			return val.Interface()
+		}
+		elem := val.Type().Elem()
+		if elem.Kind() != reflect.Struct && !(elem.Kind() == reflect.Ptr && elem.Elem().Kind() == reflect.Struct) {
+			return val.Interface()
+		}
+		list := make([]interface{}, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			list[i] = a.convertLegacy(val.Index(i))
+		}
+		return list
+	default:
+		return val.Interface()
+	}
+}
@@ -0,0 +1,131 @@
+package ruler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructAdapter_Map(t *testing.T) {
+	type Exams struct {
+		Math   int `json:"math"`
+		Physic int `json:"physic,omitempty"`
+	}
+	type Base struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	type Student struct {
+		Base
+		Name    string            `json:"nick"`
+		Score   *Exams            `json:"score"`
+		Tags    []*Exams          `json:"tags"`
+		History map[string]Exams  `json:"history"`
+		Extra   interface{}       `json:"extra"`
+		Secret  string            `json:"-"`
+		Born    time.Time         `json:"born"`
+		Labels  map[string]string `json:"labels"`
+	}
+	born := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &Student{
+		Base:    Base{ID: 7, Name: "base"},
+		Name:    "chris",
+		Score:   &Exams{Math: 90},
+		Tags:    []*Exams{{Math: 1, Physic: 2}},
+		History: map[string]Exams{"2019": {Math: 60, Physic: 70}},
+		Extra:   &Exams{Math: 3},
+		Secret:  "hide",
+		Born:    born,
+		Labels:  map[string]string{"a": "b"},
+	}
+	m := NewStructAdapter("json").Map(s)
+	expect := map[string]interface{}{
+		"id":      7,
+		"name":    "base",
+		"nick":    "chris",
+		"score":   map[string]interface{}{"math": 90},
+		"tags":    []interface{}{map[string]interface{}{"math": 1, "physic": 2}},
+		"history": map[string]interface{}{"2019": map[string]interface{}{"math": 60, "physic": 70}},
+		"extra":   map[string]interface{}{"math": 3},
+		"born":    born,
+		"labels":  map[string]interface{}{"a": "b"},
+	}
+	assert.Equal(t, expect, m)
+}
+
+func TestStructAdapter_MapDefault(t *testing.T) {
+	type Inner struct {
+		A int
+	}
+	type Outer struct {
+		Inner
+		B *Inner
+		C string `structs:"c"`
+		D string `structs:"-"`
+	}
+	m := defaultAdapter.Map(&Outer{Inner: Inner{A: 1}, C: "c", D: "d"})
+	assert.Equal(t, map[string]interface{}{"A": 1}, m["Inner"])
+	assert.Equal(t, "c", m["c"])
+	assert.Nil(t, m["B"])
+	_, ok := m["D"]
+	assert.False(t, ok)
+}
+
+func TestRules_FitWithAdapter(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": ">=", "key": "score.math", "val": 90, "id": 1, "msg": "Math not so well"},
+	{"op": "=", "key": "grade", "val": 3, "id": 2, "msg": "Grade not match"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "1 and 2")
+	if err != nil {
+		t.Error(err)
+	}
+	rs.Adapter = NewStructAdapter("json")
+
+	type Exams struct {
+		Math int `json:"math"`
+	}
+	type Meta struct {
+		Grade int `json:"grade"`
+	}
+	type Student struct {
+		*Meta
+		Score Exams `json:"score"`
+	}
+	fit, _ := rs.Fit(&Student{Meta: &Meta{Grade: 3}, Score: Exams{Math: 95}})
+	assert.True(t, fit)
+
+	// the same rules fit raw json
+	fit, _ = rs.FitWithMap(map[string]interface{}{"grade": 3, "score": map[string]interface{}{"math": 80}})
+	assert.False(t, fit)
+}
+
+func TestStructAdapter_MapUnexportedEmbedded(t *testing.T) {
+	type inner struct {
+		Grade int `json:"grade"`
+		hidden int
+	}
+	type level struct {
+		Level int `json:"level"`
+	}
+	type Student struct {
+		inner
+		*level
+		Name string `json:"name"`
+	}
+	o := Student{inner: inner{Grade: 3, hidden: 1}, Name: "n"}
+	adapter := NewStructAdapter("json")
+	// 与encoding/json一致：未导出嵌入结构体的可导出字段被平铺，nil指针跳过
+	assert.Equal(t, map[string]interface{}{"grade": 3, "name": "n"}, adapter.Map(o))
+	o.level = &level{Level: 2}
+	assert.Equal(t, map[string]interface{}{"grade": 3, "level": 2, "name": "n"}, adapter.Map(&o))
+	// structs.Map不读取未导出字段
+	assert.Equal(t, map[string]interface{}{"Name": "n"}, defaultAdapter.Map(o))
+
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "grade", "val": 3, "id": 1}, {"op": "=", "key": "level", "val": 2, "id": 2}]`), "")
+	assert.Nil(t, err)
+	rs.Adapter = adapter
+	fit, _ := rs.Fit(&o)
+	assert.True(t, fit)
+}
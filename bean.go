@@ -1,21 +1,42 @@
 package ruler
 
+import (
+	"sync/atomic"
+	"text/template"
+)
+
 // Rule 最小单元，子规则
 type Rule struct {
-	Op  string      `json:"op"`  // 算符
-	Key string      `json:"key"` // 目标变量键名
-	Val interface{} `json:"val"` // 目标变量子规则存值
-	ID  int         `json:"id"`  // 子规则ID
-	Msg string      `json:"msg"` // 该规则抛出的负提示
+	Op  string      `json:"op" yaml:"op" toml:"op"`              // 算符
+	Key string      `json:"key" yaml:"key" toml:"key"`           // 目标变量键名
+	Val interface{} `json:"val" yaml:"val" toml:"val"`           // 目标变量子规则存值
+	ID  int         `json:"id" yaml:"id" toml:"id"`              // 子规则ID
+	Msg string      `json:"msg" yaml:"msg,omitempty" toml:"msg"` // 该规则抛出的负提示
+
+	Msgs map[string]string `json:"msgs,omitempty" yaml:"msgs,omitempty" toml:"msgs"` // 按语言的负提示，如 "zh-CN": "年级不符"
+
+	PassMsg  string            `json:"passMsg,omitempty" yaml:"passMsg,omitempty" toml:"passMsg,omitempty"`    // 该规则满足时的正提示，为空时沿用msg
+	PassMsgs map[string]string `json:"passMsgs,omitempty" yaml:"passMsgs,omitempty" toml:"passMsgs"`           // 按语言的正提示
+	Severity Severity          `json:"severity,omitempty" yaml:"severity,omitempty" toml:"severity,omitempty"` // 级别，为空时为error
+
+	compiled  interface{}                   // 构造时预处理的值，见compile
+	lazy      atomic.Value                  // 未经构造方法的子规则首次使用时预处理的值，存*lazyCompiled
+	templates map[string]*template.Template // 构造时解析的提示模板，按msg索引
 }
 
 // Rules 规则，拥有逻辑表达式
 type Rules struct {
-	Rules []*Rule     // 子规则集合
-	Logic string      // 逻辑表达式，使用子规则ID运算表达
-	Name  string      // 规则名称
-	Msg   string      // 规则抛出的负提示
-	Val   interface{} // 改规则所代表的存值
+	Rules []*Rule           // 子规则集合
+	Logic string            // 逻辑表达式，使用子规则ID运算表达
+	Name  string            // 规则名称
+	Msg   string            // 规则抛出的负提示
+	Val   interface{}       // 改规则所代表的存值
+	Msgs  map[string]string // 按语言的负提示
+
+	Adapter         *StructAdapter // Fit结构体时使用的适配器，nil时兼容structs.Map
+	Translator      Translator     // 把msg作为消息键按语言翻译，可以为nil
+	FallbackLocales []string       // 指定语言没有提示时依次尝试的语言，如 ["en"]
+	plans           atomic.Value   // 按结构体类型缓存的取值计划，存*sync.Map，首次匹配结构体时创建
 }
 
 // RulesList 规则组，顺序即优先级
@@ -23,6 +44,51 @@ type RulesList struct {
 	RulesList []*Rules
 	Name      string
 	Msg       string
+
+	Adapter *StructAdapter // Fit结构体时使用的适配器，nil时兼容structs.Map
+}
+
+// Severity 子规则的级别
+type Severity string
+
+const (
+	// SeverityError 不满足时Fit为false，默认级别
+	SeverityError Severity = "error"
+	// SeverityWarning 警告，FitWarn时不满足也不影响Fit
+	SeverityWarning Severity = "warning"
+	// SeverityInfo 提示，FitWarn时不满足也不影响Fit
+	SeverityInfo Severity = "info"
+)
+
+// Truth 三值逻辑的真值
+type Truth int8
+
+const (
+	// TruthFalse 假
+	TruthFalse Truth = iota
+	// TruthTrue 真
+	TruthTrue
+	// TruthUnknown 因数据缺失无法判定
+	TruthUnknown
+)
+
+func truthOf(b bool) Truth {
+	if b {
+		return TruthTrue
+	}
+	return TruthFalse
+}
+
+// String 真值的字符串表示
+func (t Truth) String() string {
+	switch t {
+	case TruthTrue:
+		return "true"
+	case TruthFalse:
+		return "false"
+	default:
+		return "unknown"
+	}
 }
 
 // ValidOperators 有效逻辑运算符
@@ -33,6 +99,7 @@ type Node struct {
 	Expr       string  // 分割的logic表达式
 	ChildrenOp string  // 孩子树之间的运算符: and, or, not
 	Val        bool    // 节点值
+	Unknown    bool    // 三值逻辑下节点值无法判定
 	Computed   bool    // 节点值被计算过
 	Leaf       bool    // 是否叶子节点
 	Should     bool    // 为了Fit为true，此节点必须的值
@@ -52,4 +119,4 @@ const (
 )
 
 // ValidAtomOperatorsDisplay 有效子规则运算符-展示
-var ValidAtomOperatorsDisplay = []string{"=", ">", "<", ">=", "<=", "!=", "in", "nin", "regex", "empty", "nempty", "between", "intersect"}
+var ValidAtomOperatorsDisplay = []string{"=", ">", "<", ">=", "<=", "!=", "in", "nin", "regex", "empty", "nempty", "between", "intersect", "subset", "superset", "exists", "notexists", "null", "notnull", "blank", "notblank", "ipin", "ipnin", "ver=", "ver>", "ver>=", "ver<", "ver<=", "verbetween", "semver", "georadius", "geopolygon", "format", "like", "nlike", "ilike", "nilike", "glob", "iglob", "levenshtein", "damerau", "jarowinkler"}
@@ -4,8 +4,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-
-	"github.com/fatih/structs"
 )
 
 // NewRulesWithJSONAndLogicAndInfo 用json串构造Rules的完全方法，logic表达式如果没有则传空字符串, ["name": "规则名称", "msg": "规则不符合的提示"]
@@ -28,29 +26,26 @@ func NewRulesWithArrayAndLogicAndInfo(rules []*Rule, logic string, extractInfo m
 
 // NewRulesWithJSONAndLogic 用json串构造Rules的标准方法，logic表达式如果没有则传空字符串
 func NewRulesWithJSONAndLogic(jsonStr []byte, logic string) (*Rules, error) {
-	if logic == "" {
-		// empty logic
-		return newRulesWithJSON(jsonStr)
-	}
 	rulesObj, err := newRulesWithJSON(jsonStr)
 	if err != nil {
 		return nil, err
 	}
-	rulesObj, err = injectLogic(rulesObj, logic)
-	if err != nil {
-		return nil, err
-	}
-
-	return rulesObj, nil
+	return NewRulesWithArrayAndLogic(rulesObj.Rules, logic)
 }
 
 // NewRulesWithArrayAndLogic 用rule数组构造Rules的标准方法，logic表达式如果没有则传空字符串
 func NewRulesWithArrayAndLogic(rules []*Rule, logic string) (*Rules, error) {
+	rulesObj := newRulesWithArray(rules)
+	if err := rulesObj.validKeys(); err != nil {
+		return nil, err
+	}
+	if err := rulesObj.compile(); err != nil {
+		return nil, err
+	}
 	if logic == "" {
 		// empty logic
-		return newRulesWithArray(rules), nil
+		return rulesObj, nil
 	}
-	rulesObj := newRulesWithArray(rules)
 	rulesObj, err := injectLogic(rulesObj, logic)
 	if err != nil {
 		return nil, err
@@ -61,8 +56,8 @@ func NewRulesWithArrayAndLogic(rules []*Rule, logic string) (*Rules, error) {
 
 // Fit Rules匹配传入结构体
 func (rs *Rules) Fit(o interface{}) (bool, map[int]string) {
-	m := structs.Map(o)
-	return rs.FitWithMap(m)
+	fit, tips, _ := rs.fitStructInFact(rs.Adapter, o)
+	return fit, tips
 }
 
 // FitWithMap Rules匹配map
@@ -73,8 +68,7 @@ func (rs *Rules) FitWithMap(o map[string]interface{}) (bool, map[int]string) {
 
 // FitAskVal Rules匹配结构体，同时返回所有子规则key值
 func (rs *Rules) FitAskVal(o interface{}) (bool, map[int]string, map[int]interface{}) {
-	m := structs.Map(o)
-	return rs.FitWithMapAskVal(m)
+	return rs.fitStructInFact(rs.Adapter, o)
 }
 
 // FitWithMapAskVal Rules匹配map，同时返回所有子规则key值
@@ -82,6 +76,18 @@ func (rs *Rules) FitWithMapAskVal(o map[string]interface{}) (bool, map[int]strin
 	return rs.fitWithMapInFact(o)
 }
 
+// FitTernary Rules以三值逻辑匹配结构体：key缺失的子规则为Unknown，结果为Unknown时同时返回导致无法判定的key
+func (rs *Rules) FitTernary(o interface{}) (Truth, map[int]string, []string) {
+	answer, tips, _, missing := rs.judge(rs.structGetter(rs.Adapter, o), true, EmptyStr, nil, nil)
+	return answer, tips, missing
+}
+
+// FitWithMapTernary Rules以三值逻辑匹配map
+func (rs *Rules) FitWithMapTernary(o map[string]interface{}) (Truth, map[int]string, []string) {
+	answer, tips, _, missing := rs.judge(mapGetter(o), true, EmptyStr, nil, nil)
+	return answer, tips, missing
+}
+
 // GetRuleIDsByLogicExpression 根据逻辑表达式得到规则id列表
 func GetRuleIDsByLogicExpression(logic string) ([]int, error) {
 	var result []int
@@ -133,8 +139,12 @@ func NewRulesList(listRules []*Rules, extractInfo map[string]string) *RulesList
 
 // Fit RulesList's fit, means hitting first rules in array
 func (rst *RulesList) Fit(o interface{}) *Rules {
-	m := structs.Map(o)
-	return rst.FitWithMap(m)
+	for _, rs := range rst.RulesList {
+		if flag, _, _ := rs.fitStructInFact(rst.Adapter, o); flag {
+			return rs
+		}
+	}
+	return nil
 }
 
 // FitWithMap RulesList's fit, means hitting first rules in array
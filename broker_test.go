@@ -293,3 +293,66 @@ func TestRulesList_Fit(t *testing.T) {
 	result := rst.Fit(o)
 	assert.NotNil(t, result)
 }
+
+func TestRules_FitTernary(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "<", "key": "Debt", "val": 100, "id": 1, "msg": "Debt too much"},
+	{"op": "=", "key": "Grade", "val": 3, "id": 2, "msg": "Grade not match"},
+	{"op": "nempty", "key": "Name", "val": "", "id": 3, "msg": "no name"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	if err != nil {
+		t.Error(err)
+	}
+	// legacy two-valued fit treats missing Debt as 0
+	fit, _ := rs.FitWithMap(map[string]interface{}{"Grade": 3, "Name": "a"})
+	assert.True(t, fit)
+
+	answer, msg, missing := rs.FitWithMapTernary(map[string]interface{}{"Grade": 3, "Name": "a"})
+	assert.Equal(t, TruthUnknown, answer)
+	assert.Equal(t, map[int]string{1: "Debt too much"}, msg)
+	assert.Equal(t, []string{"Debt"}, missing)
+
+	// a false sub rule decides regardless of missing data
+	answer, msg, missing = rs.FitWithMapTernary(map[string]interface{}{"Grade": 4})
+	assert.Equal(t, TruthFalse, answer)
+	assert.Equal(t, map[int]string{2: "Grade not match", 3: "no name"}, msg)
+	assert.Nil(t, missing)
+
+	answer, _, _ = rs.FitWithMapTernary(map[string]interface{}{"Debt": 1, "Grade": 3, "Name": "a"})
+	assert.Equal(t, TruthTrue, answer)
+}
+
+func TestRules_FitTernaryWithLogic(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "=", "key": "A", "val": 1, "id": 1, "msg": "A"},
+	{"op": "=", "key": "B.C", "val": 1, "id": 2, "msg": "B"},
+	{"op": "=", "key": "D", "val": 1, "id": 3, "msg": "D"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "1 and (2 or not 3)")
+	if err != nil {
+		t.Error(err)
+	}
+	type B struct {
+		C int
+	}
+	type Obj struct {
+		A int
+		B *B
+		D int
+	}
+	// B is nil, so B.C is missing
+	answer, _, missing := rs.FitTernary(&Obj{A: 1, D: 1})
+	assert.Equal(t, TruthUnknown, answer)
+	assert.Equal(t, []string{"B.C"}, missing)
+
+	// not 3 is true, so unknown 2 does not matter
+	answer, msg, missing := rs.FitTernary(&Obj{A: 1, D: 0})
+	assert.Equal(t, TruthTrue, answer)
+	assert.Nil(t, missing)
+	assert.Equal(t, "D", msg[3])
+
+	answer, msg, _ = rs.FitTernary(&Obj{A: 0})
+	assert.Equal(t, TruthFalse, answer)
+	assert.Equal(t, map[int]string{1: "A"}, msg)
+}
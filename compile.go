@@ -0,0 +1,93 @@
+package ruler
+
+import "fmt"
+
+/**
+  构造时预处理子规则的值：列表转为集合、区间预先解析等，格式错误在构造时即报错
+  预处理结果存于Rule.compiled，未经构造方法的子规则在首次使用时预处理并缓存；构造完成后不应再修改子规则的Op、Val
+*/
+
+// compile 预处理所有子规则
+func (rs *Rules) compile() error {
+	for _, rule := range rs.Rules {
+		compiled, err := rule.compile()
+		if err != nil {
+			return fmt.Errorf("rule %d: %s", rule.ID, err.Error())
+		}
+		templates, err := rule.parseTemplates()
+		if err != nil {
+			return fmt.Errorf("rule %d: %s", rule.ID, err.Error())
+		}
+		if err := checkLocales(rule.Msgs); err != nil {
+			return fmt.Errorf("rule %d: msgs: %s", rule.ID, err.Error())
+		}
+		if err := checkLocales(rule.PassMsgs); err != nil {
+			return fmt.Errorf("rule %d: passMsgs: %s", rule.ID, err.Error())
+		}
+		switch rule.Severity {
+		case EmptyStr, SeverityError, SeverityWarning, SeverityInfo:
+		default:
+			return fmt.Errorf("rule %d: unknown severity %q", rule.ID, rule.Severity)
+		}
+		rule.compiled, rule.templates = compiled, templates
+	}
+	return nil
+}
+
+// compile 按算符预处理子规则的值，无需预处理时返回nil
+func (r *Rule) compile() (interface{}, error) {
+	switch r.Op {
+	case "@", "in", "!@", "nin":
+		if !isList(r.Val) {
+			// 逗号分隔的字符串，沿用原有逻辑
+			return nil, nil
+		}
+		return newValueSet(r.Val)
+	case "@@", "intersect", "subset", "superset":
+		return newValueSet(r.Val)
+	case "<<", "between":
+		scope, ok := r.Val.(string)
+		if !ok {
+			return nil, fmt.Errorf("value should be an interval string, got %T", r.Val)
+		}
+		return parseIntervals(scope)
+	case "ipin", "ipnin":
+		return newIPSet(r.Val)
+	case "ver=", "ver>", "ver>=", "ver<", "ver<=", "verbetween", "semver":
+		return compileVersion(r.Op, r.Val)
+	case "georadius":
+		return newGeoCircle(r.Val)
+	case "geopolygon":
+		return newGeoShape(r.Val)
+	case "format":
+		return newFormatChecker(r.Val)
+	case "like", "nlike", "ilike", "nilike", "glob", "iglob":
+		return compilePattern(r.Op, r.Val)
+	case "levenshtein", "damerau", "jarowinkler":
+		return newFuzzyTargets(r.Op, r.Val)
+	default:
+		return nil, nil
+	}
+}
+
+// lazyCompiled 首次使用时预处理的结果，值格式错误时为nil
+type lazyCompiled struct {
+	val interface{}
+}
+
+// compiledVal 预处理后的值，未经构造方法预处理的子规则在首次使用时处理并缓存
+// 预处理的结果不会随Op、Val的修改而更新，需要修改时应重新构造Rules
+func (r *Rule) compiledVal() interface{} {
+	if r.compiled != nil {
+		return r.compiled
+	}
+	if cached, ok := r.lazy.Load().(*lazyCompiled); ok {
+		return cached.val
+	}
+	compiled, err := r.compile()
+	if err != nil {
+		compiled = nil
+	}
+	r.lazy.Store(&lazyCompiled{val: compiled})
+	return compiled
+}
@@ -3,6 +3,7 @@ package ruler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math/rand"
 	"reflect"
 	"regexp"
@@ -89,66 +90,183 @@ func newRulesWithArray(rules []*Rule) *Rules {
 	}
 }
 
+// valueGetter 取得子规则key对应的实际值，以及key是否存在
+type valueGetter func(index int, rule *Rule) (interface{}, bool)
+
+func mapGetter(o map[string]interface{}) valueGetter {
+	return func(_ int, rule *Rule) (interface{}, bool) {
+		if keys := rule.keyPaths(); len(keys) == 2 {
+			lat, latOK := lookup(keys[0], o)
+			lon, lonOK := lookup(keys[1], o)
+			return joinLatLon(lat, latOK, lon, lonOK)
+		}
+		return lookup(rule.Key, o)
+	}
+}
+
+func (rs *Rules) structGetter(adapter *StructAdapter, o interface{}) valueGetter {
+	plan, root := rs.planOf(adapter, o)
+	if plan == nil {
+		return mapGetter(adapter.Map(o))
+	}
+	return func(index int, rule *Rule) (interface{}, bool) {
+		if index < len(plan.accessors) {
+			return plan.accessors[index].get(root)
+		}
+		// 匹配过程中新增的子规则，退回到转换后再取值
+		return mapGetter(adapter.Map(o))(index, rule)
+	}
+}
+
 func (rs *Rules) fitWithMapInFact(o map[string]interface{}) (bool, map[int]string, map[int]interface{}) {
-	var results = make(map[int]bool)
-	var tips = make(map[int]string)
+	return rs.fitInFact(mapGetter(o), EmptyStr)
+}
+
+func (rs *Rules) fitStructInFact(adapter *StructAdapter, o interface{}) (bool, map[int]string, map[int]interface{}) {
+	return rs.fitInFact(rs.structGetter(adapter, o), EmptyStr)
+}
+
+func (rs *Rules) fitInFact(get valueGetter, locale string) (bool, map[int]string, map[int]interface{}) {
+	answer, tips, values, _ := rs.judge(get, false, locale, nil, nil)
+	return answer == TruthTrue, tips, values
+}
+
+// judge 匹配的核心方法，kleene为true时key缺失的子规则为Unknown，按三值逻辑计算，结果为Unknown时返回导致无法判定的key
+// 提示使用locale指定的语言，为空时使用FallbackLocales
+// warnings不为nil时，warning、info级别子规则不参与计算，见warn；results不为nil时记录每条子规则的真值
+func (rs *Rules) judge(get valueGetter, kleene bool, locale string, warnings map[int]string, results map[int]Truth) (Truth, map[int]string, map[int]interface{}, []string) {
+	if results == nil {
+		results = make(map[int]Truth)
+	}
 	var values = make(map[int]interface{})
 	var hasLogic = false
-	var allRuleIDs []int
+	var allRuleIDs, unknownIDs []int
 	if rs.Logic != EmptyStr {
 		hasLogic = true
 	}
-	for _, rule := range rs.Rules {
-		v := pluck(rule.Key, o)
-		if v != nil && rule.Val != nil {
+	for index, rule := range rs.Rules {
+		v, exists := get(index, rule)
+		if v != nil && rule.Val != nil && rule.scalarOnly() {
 			typeV := reflect.TypeOf(v)
 			typeR := reflect.TypeOf(rule.Val)
 			if !typeV.Comparable() || !typeR.Comparable() {
-				return false, nil, nil
+				return TruthFalse, nil, nil, nil
 			}
 		}
 		values[rule.ID] = v
+		counted := warnings == nil || rule.blocking()
+		if counted {
+			allRuleIDs = append(allRuleIDs, rule.ID)
+		}
 
-		flag := rule.fit(v)
-		results[rule.ID] = flag
-		if !flag {
-			// fit false, record msg, for no logic expression usage
-			tips[rule.ID] = rule.Msg
+		if kleene && !exists && !rule.testsPresence() {
+			results[rule.ID] = TruthUnknown
+			if counted {
+				unknownIDs = append(unknownIDs, rule.ID)
+			}
+			continue
+		}
+		var flag bool
+		if match, ok := rule.fuzzyMatch(v); ok {
+			// 模糊匹配在values中给出最接近的目标和相似度
+			values[rule.ID] = match
+			flag = match.Matched
+		} else {
+			flag = rule.fitPresent(v, exists)
+		}
+		results[rule.ID] = truthOf(flag)
+	}
+	logic := rs.Logic
+	if warnings != nil {
+		logic = rs.warn(results, values, locale, warnings)
+		if hasLogic && logic == EmptyStr {
+			// 逻辑表达式中只有warning、info级别的子规则
+			return TruthTrue, make(map[int]string), values, nil
 		}
-		allRuleIDs = append(allRuleIDs, rule.ID)
 	}
 	// compute result by considering logic
 
 	if !hasLogic {
-		for _, flag := range results {
-			if !flag {
-				return false, tips, values
+		// fit false, record msg of all failed rules
+		var failedIDs []int
+		for _, rule := range rs.Rules {
+			if results[rule.ID] == TruthFalse && (warnings == nil || rule.blocking()) {
+				failedIDs = append(failedIDs, rule.ID)
 			}
 		}
-		return true, rs.getTipsByRuleIDs(allRuleIDs), values
+		if len(failedIDs) > 0 {
+			return TruthFalse, rs.getTipsByRuleIDs(failedIDs, values, locale, results), values, nil
+		}
+		if len(unknownIDs) > 0 {
+			return TruthUnknown, rs.getTipsByRuleIDs(unknownIDs, values, locale, results), values, rs.getKeysByRuleIDs(unknownIDs)
+		}
+		return TruthTrue, rs.getTipsByRuleIDs(allRuleIDs, values, locale, results), values, nil
 	}
-	answer, ruleIDs, err := rs.calculateExpressionByTree(results)
+	answer, ruleIDs, err := rs.calculateExpressionByTree(logic, results)
 	// tree can return fail reasons in fact
-	tips = rs.getTipsByRuleIDs(ruleIDs)
+	tips := rs.getTipsByRuleIDs(ruleIDs, values, locale, results)
 	if err != nil {
-		return false, nil, values
+		return TruthFalse, nil, values, nil
+	}
+	if answer == TruthUnknown {
+		return answer, tips, values, rs.getKeysByRuleIDs(ruleIDs)
 	}
-	return answer, tips, values
+	return answer, tips, values, nil
 }
 
-func (rs *Rules) getTipsByRuleIDs(ids []int) map[int]string {
-	var tips = make(map[int]string)
-	var allTips = make(map[int]string)
+// getKeysByRuleIDs 子规则的key，按子规则顺序去重
+func (rs *Rules) getKeysByRuleIDs(ids []int) []string {
+	var keys []string
+	var mapID = make(map[int]bool)
+	var mapKey = make(map[string]bool)
+	for _, id := range ids {
+		mapID[id] = true
+	}
 	for _, rule := range rs.Rules {
-		allTips[rule.ID] = rule.Msg
+		if mapID[rule.ID] && !mapKey[rule.Key] {
+			keys = append(keys, rule.Key)
+			mapKey[rule.Key] = true
+		}
 	}
+	return keys
+}
+
+// getTipsByRuleIDs 只渲染ids中子规则的提示，按子规则自身的结果选择正负提示
+func (rs *Rules) getTipsByRuleIDs(ids []int, values map[int]interface{}, locale string, results map[int]Truth) map[int]string {
+	var tips = make(map[int]string, len(ids))
 	for _, id := range ids {
-		tips[id] = allTips[id]
+		tips[id] = EmptyStr
+	}
+	for _, rule := range rs.Rules {
+		if _, ok := tips[rule.ID]; ok {
+			tips[rule.ID] = rs.tipIn(rule, values[rule.ID], locale, results[rule.ID] == TruthTrue)
+		}
 	}
 	return tips
 }
 
 func (r *Rule) fit(v interface{}) bool {
+	if flag, ok := r.fitList(v); ok {
+		return flag
+	}
+	if flag, ok := r.fitIP(v); ok {
+		return flag
+	}
+	if flag, ok := r.fitVersion(v); ok {
+		return flag
+	}
+	if flag, ok := r.fitGeo(v); ok {
+		return flag
+	}
+	if flag, ok := r.fitFormat(v); ok {
+		return flag
+	}
+	if flag, ok := r.fitPattern(v); ok {
+		return flag
+	}
+	if flag, ok := r.fitFuzzy(v); ok {
+		return flag
+	}
 	op := r.Op
 	// judge if need convert to uniform type
 	var ok bool
@@ -191,6 +309,26 @@ func (r *Rule) fit(v interface{}) bool {
 		return false
 	}
 
+	// json数字按十进制精确比较
+	if isNum && (isDecimal(v) || isDecimal(r.Val)) {
+		if cmp, ok := compareDecimal(v, r.Val); ok {
+			switch op {
+			case "=", "eq":
+				return cmp == 0
+			case ">", "gt":
+				return cmp > 0
+			case "<", "lt":
+				return cmp < 0
+			case ">=", "gte":
+				return cmp >= 0
+			case "<=", "lte":
+				return cmp <= 0
+			case "!=", "neq":
+				return cmp != 0
+			}
+		}
+	}
+
 	switch op {
 	case "=", "eq":
 		if isNum {
@@ -246,12 +384,9 @@ func (r *Rule) fit(v interface{}) bool {
 		return !isIn(pairStr[0], pairStr[1], !isObjStr)
 	case "^$", "regex":
 		return checkRegex(pairStr[1], pairStr[0])
-	case "0", "empty":
-		return v == nil
-	case "1", "nempty":
-		return v != nil
 	case "<<", "between":
-		return isBetween(pairNum[0], pairStr[1])
+		set, _ := r.compiledVal().(intervalSet)
+		return set.contains(v)
 	case "@@", "intersect":
 		return isIntersect(pairStr[1], pairStr[0])
 	default:
@@ -260,19 +395,113 @@ func (r *Rule) fit(v interface{}) bool {
 }
 
 func pluck(key string, o map[string]interface{}) interface{} {
+	v, _ := lookup(key, o)
+	return v
+}
+
+// lookup 同pluck，同时返回key是否存在
+func lookup(key string, o map[string]interface{}) (interface{}, bool) {
 	if o == nil || key == EmptyStr {
-		return nil
+		return nil, false
 	}
-	paths := strings.Split(key, ".")
-	var ok bool
-	for index, step := range paths {
-		// last step is object key
-		if index == len(paths)-1 {
-			return o[step]
+	paths, err := parseKeyPath(key)
+	if err != nil {
+		return nil, false
+	}
+	return lookupPath(paths, o)
+}
+
+// testsPresence 算符本身判断key是否存在或为空，缺失时不算Unknown
+func (r *Rule) testsPresence() bool {
+	switch r.Op {
+	case "0", "empty", "1", "nempty", "exists", "notexists", "null", "notnull", "blank", "notblank":
+		return true
+	default:
+		return false
+	}
+}
+
+// scalarOnly 算符只比较标量，实际值或规则值不可比较时整个Rules不匹配
+func (r *Rule) scalarOnly() bool {
+	switch r.Op {
+	case "=", "eq", ">", "gt", "<", "lt", ">=", "gte", "<=", "lte", "!=", "neq", "^$", "regex", "<<", "between":
+		return true
+	default:
+		return false
+	}
+}
+
+// fitPresent 子规则匹配，exists表示key是否存在
+func (r *Rule) fitPresent(v interface{}, exists bool) bool {
+	switch r.Op {
+	case "0", "empty":
+		return v == nil
+	case "1", "nempty":
+		return v != nil
+	case "exists":
+		return exists
+	case "notexists":
+		return !exists
+	case "null":
+		return exists && isNull(v)
+	case "notnull":
+		return exists && !isNull(v)
+	case "blank":
+		return !exists || isBlank(v)
+	case "notblank":
+		return exists && !isBlank(v)
+	default:
+		return r.fit(v)
+	}
+}
+
+// isNull 值为nil，或是值为nil的指针、接口、map、切片
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// isBlank 值为null、零值、空串、空切片或空map，指针取其指向的值判断
+func isBlank(v interface{}) bool {
+	if n, ok := v.(json.Number); ok {
+		cmp, ok := compareDecimal(n, 0)
+		return ok && cmp == 0
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return true
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Map, reflect.Slice, reflect.String, reflect.Chan:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
+
+// validKeys 检查所有子规则key的路径语法
+func (rs *Rules) validKeys() error {
+	for _, rule := range rs.Rules {
+		if rule.Key == EmptyStr {
+			continue
 		}
-		// explore deeper
-		if o, ok = o[step].(map[string]interface{}); !ok {
-			return nil
+		for _, key := range rule.keyPaths() {
+			if _, err := parseKeyPath(key); err != nil {
+				return fmt.Errorf("rule %d: %s", rule.ID, err.Error())
+			}
 		}
 	}
 	return nil
@@ -304,6 +533,9 @@ func formatNumber(v interface{}) float64 {
 		return float64(t)
 	case float64:
 		return t
+	case json.Number:
+		f, _ := t.Float64()
+		return f
 	default:
 		return 0
 	}
@@ -449,6 +681,39 @@ func numOfOperandInLogic(op string) int8 {
 	return mapOperand[op]
 }
 
+// computeOneInLogicTri Kleene三值逻辑运算
+func computeOneInLogicTri(op string, v []Truth) (Truth, error) {
+	switch op {
+	case "or":
+		if v[0] == TruthTrue || v[1] == TruthTrue {
+			return TruthTrue, nil
+		}
+		if v[0] == TruthUnknown || v[1] == TruthUnknown {
+			return TruthUnknown, nil
+		}
+		return TruthFalse, nil
+	case "and":
+		if v[0] == TruthFalse || v[1] == TruthFalse {
+			return TruthFalse, nil
+		}
+		if v[0] == TruthUnknown || v[1] == TruthUnknown {
+			return TruthUnknown, nil
+		}
+		return TruthTrue, nil
+	case "not":
+		switch v[0] {
+		case TruthTrue:
+			return TruthFalse, nil
+		case TruthFalse:
+			return TruthTrue, nil
+		default:
+			return TruthUnknown, nil
+		}
+	default:
+		return TruthFalse, errors.New("unrecognized op")
+	}
+}
+
 func computeOneInLogic(op string, v []bool) (bool, error) {
 	switch op {
 	case "or":
@@ -506,77 +771,3 @@ func isIntersect(objStr string, ruleStr string) bool {
 	}
 	return false
 }
-
-func isBetween(obj float64, scope string) bool {
-	scope = strings.Trim(scope, " ")
-	var equalLeft, equalRight bool
-	// [] 双闭区间
-	result := regexp.MustCompile("^\\[ *(-?\\d*.?\\d*) *, *(-?\\d*.?\\d*) *]$").FindStringSubmatch(scope)
-	if len(result) > 2 {
-		equalLeft = true
-		equalRight = true
-		return calculateBetween(obj, result, equalLeft, equalRight)
-	}
-	// [) 左闭右开区间
-	result = regexp.MustCompile("^\\[ *(-?\\d*.?\\d*) *, *(-?\\d*.?\\d*) *\\)$").FindStringSubmatch(scope)
-	if len(result) > 2 {
-		equalLeft = true
-		equalRight = false
-		return calculateBetween(obj, result, equalLeft, equalRight)
-	}
-	// (] 左开右闭区间
-	result = regexp.MustCompile("^\\( *(-?\\d*.?\\d*) *, *(-?\\d*.?\\d*) *]$").FindStringSubmatch(scope)
-	if len(result) > 2 {
-		equalLeft = false
-		equalRight = true
-		return calculateBetween(obj, result, equalLeft, equalRight)
-	}
-	// () 双开区间
-	result = regexp.MustCompile("^\\( *(-?\\d*.?\\d*) *, *(-?\\d*.?\\d*) *\\)$").FindStringSubmatch(scope)
-	if len(result) > 2 {
-		equalLeft = false
-		equalRight = false
-		return calculateBetween(obj, result, equalLeft, equalRight)
-	}
-	return false
-}
-
-func calculateBetween(obj float64, result []string, equalLeft, equalRight bool) bool {
-	var hasLeft, hasRight bool
-	var left, right float64
-	var err error
-	if result[1] != "" {
-		hasLeft = true
-		left, err = strconv.ParseFloat(result[1], 64)
-		if err != nil {
-			return false
-		}
-	}
-	if result[2] != "" {
-		hasRight = true
-		right, err = strconv.ParseFloat(result[2], 64)
-		if err != nil {
-			return false
-		}
-	}
-	// calculate
-	if !hasLeft && !hasRight {
-		return false
-	}
-	flag := true
-	if hasLeft {
-		if equalLeft {
-			flag = flag && obj >= left
-		} else {
-			flag = flag && obj > left
-		}
-	}
-	if hasRight {
-		if equalRight {
-			flag = flag && obj <= right
-		} else {
-			flag = flag && obj < right
-		}
-	}
-	return flag
-}
@@ -1,6 +1,8 @@
 package ruler
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -294,3 +296,71 @@ func TestValidLogic(t *testing.T) {
 	}
 	assert.Equal(t, "1 and 2 or ( 3 or not 4 )", formatLogic)
 }
+
+func TestRule_FitPresent(t *testing.T) {
+	var nilPtr *int
+	zero := 0
+	cases := []struct {
+		op     string
+		v      interface{}
+		exists bool
+		expect bool
+	}{
+		{"exists", nil, true, true},
+		{"exists", nil, false, false},
+		{"notexists", nil, false, true},
+		{"null", nil, true, true},
+		{"null", nil, false, false},
+		{"null", nilPtr, true, true},
+		{"null", []int(nil), true, true},
+		{"null", 0, true, false},
+		{"notnull", 0, true, true},
+		{"notnull", nil, false, false},
+		{"blank", nil, false, true},
+		{"blank", 0, true, true},
+		{"blank", "", true, true},
+		{"blank", &zero, true, true},
+		{"blank", []int{}, true, true},
+		{"blank", map[string]interface{}{}, true, true},
+		{"blank", json.Number("0.0"), true, true},
+		{"blank", " ", true, false},
+		{"notblank", []int{0}, true, true},
+		{"notblank", false, true, false},
+		{"empty", nil, false, true},
+		{"nempty", "a", true, true},
+	}
+	for _, c := range cases {
+		rule := &Rule{Op: c.op, Key: "status", Val: "anything"}
+		assert.Equal(t, c.expect, rule.fitPresent(c.v, c.exists), fmt.Sprintf("%s %#v %v", c.op, c.v, c.exists))
+	}
+}
+
+func TestRules_FitPresenceOperators(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "exists", "key": "Score", "id": 1, "msg": "no score"},
+	{"op": "notnull", "key": "Score", "id": 2, "msg": "score is null"},
+	{"op": "notblank", "key": "Name", "id": 3, "msg": "name is blank"},
+	{"op": "notexists", "key": "Deleted", "id": 4, "msg": "deleted"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	if err != nil {
+		t.Error(err)
+	}
+	fit, msg := rs.FitWithMap(map[string]interface{}{"Score": nil, "Name": "", "Deleted": nil})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "score is null", 3: "name is blank", 4: "deleted"}, msg)
+
+	type Exams struct {
+		Math int
+	}
+	type Student struct {
+		Name  string
+		Score *Exams
+	}
+	fit, msg = rs.Fit(&Student{Name: "chris"})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "score is null"}, msg)
+
+	fit, _ = rs.Fit(&Student{Name: "chris", Score: &Exams{}})
+	assert.True(t, fit)
+}
@@ -0,0 +1,257 @@
+package ruler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+/**
+  规则文档：Rules和RulesList完整的json格式，可整体存为一个文件或一行数据
+  {"version": 1, "kind": "rules", "name": "...", "msg": "...", "logic": "1 and 2", "val": ..., "rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]}
+  {"version": 1, "kind": "rulesList", "name": "...", "msg": "...", "rulesList": [{"name": "...", "logic": "...", "rules": [...]}]}
+  数字与json.Unmarshal一样读为float64，超出float64精确范围的整数保留为json.Number；未命名的Rules读取后仍未命名
+  读取时校验版本、key、算符的值和逻辑表达式，任何错误都会返回；Adapter属于运行时配置，不在文档中
+*/
+
+// DocumentVersion 当前的规则文档版本
+const DocumentVersion = 1
+
+const (
+	kindRules     = "rules"
+	kindRulesList = "rulesList"
+)
+
+// documentError 文档的校验错误，path为出错的位置，如 ["rulesList", 1, "rules", 0]，用于yaml、toml文档定位行列号
+type documentError struct {
+	path []interface{}
+	err  error
+}
+
+func (e *documentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *documentError) Unwrap() error {
+	return e.err
+}
+
+// rulesDocument Rules的文档，嵌在rulesList中时没有version和kind
+type rulesDocument struct {
+	Version int               `json:"version,omitempty" yaml:"version,omitempty" toml:"version,omitempty"`
+	Kind    string            `json:"kind,omitempty" yaml:"kind,omitempty" toml:"kind,omitempty"`
+	Name    string            `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	Msg     string            `json:"msg,omitempty" yaml:"msg,omitempty" toml:"msg,omitempty"`
+	Logic   string            `json:"logic,omitempty" yaml:"logic,omitempty" toml:"logic,omitempty"`
+	Val     interface{}       `json:"val,omitempty" yaml:"val,omitempty" toml:"val,omitempty"`
+	Msgs    map[string]string `json:"msgs,omitempty" yaml:"msgs,omitempty" toml:"msgs,omitempty"`
+	Rules   []*Rule           `json:"rules" yaml:"rules" toml:"rules"`
+}
+
+// rulesListDocument RulesList的文档
+type rulesListDocument struct {
+	Version   int              `json:"version" yaml:"version" toml:"version"`
+	Kind      string           `json:"kind" yaml:"kind" toml:"kind"`
+	Name      string           `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	Msg       string           `json:"msg,omitempty" yaml:"msg,omitempty" toml:"msg,omitempty"`
+	RulesList []*rulesDocument `json:"rulesList" yaml:"rulesList" toml:"rulesList"`
+}
+
+// LoadRules 读取并校验Rules文档
+func LoadRules(data []byte) (*Rules, error) {
+	rs := &Rules{}
+	if err := rs.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// LoadRulesList 读取并校验RulesList文档
+func LoadRulesList(data []byte) (*RulesList, error) {
+	rst := &RulesList{}
+	if err := rst.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return rst, nil
+}
+
+// MarshalJSON Rules序列化为文档
+func (rs *Rules) MarshalJSON() ([]byte, error) {
+	doc := rs.document()
+	doc.Version, doc.Kind = DocumentVersion, kindRules
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON 从文档构造Rules，校验方式与NewRulesWithArrayAndLogic一致
+func (rs *Rules) UnmarshalJSON(data []byte) error {
+	var doc rulesDocument
+	if err := decodeDocument(data, &doc); err != nil {
+		return err
+	}
+	built, err := doc.load()
+	if err != nil {
+		return err
+	}
+	rs.setFrom(built)
+	return nil
+}
+
+// MarshalJSON RulesList序列化为文档
+func (rst *RulesList) MarshalJSON() ([]byte, error) {
+	doc := rulesListDocument{Version: DocumentVersion, Kind: kindRulesList, Name: rst.Name, Msg: rst.Msg}
+	doc.RulesList = make([]*rulesDocument, 0, len(rst.RulesList))
+	for _, rs := range rst.RulesList {
+		doc.RulesList = append(doc.RulesList, rs.document())
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON 从文档构造RulesList，错误信息包含出错的Rules下标
+func (rst *RulesList) UnmarshalJSON(data []byte) error {
+	var doc rulesListDocument
+	if err := decodeDocument(data, &doc); err != nil {
+		return err
+	}
+	built, err := doc.load()
+	if err != nil {
+		return err
+	}
+	rst.RulesList, rst.Name, rst.Msg = built.RulesList, built.Name, built.Msg
+	return nil
+}
+
+func (rs *Rules) document() *rulesDocument {
+	rules := rs.Rules
+	if rules == nil {
+		rules = []*Rule{}
+	}
+	return &rulesDocument{Name: rs.Name, Msg: rs.Msg, Logic: rs.Logic, Val: rs.Val, Msgs: rs.Msgs, Rules: rules}
+}
+
+// load 校验版本和kind后构造Rules
+func (doc *rulesDocument) load() (*Rules, error) {
+	if err := checkDocument(doc.Version, doc.Kind, kindRules); err != nil {
+		return nil, err
+	}
+	return doc.build()
+}
+
+// load 校验版本和kind后构造RulesList，错误信息包含出错的Rules下标
+func (doc *rulesListDocument) load() (*RulesList, error) {
+	if err := checkDocument(doc.Version, doc.Kind, kindRulesList); err != nil {
+		return nil, err
+	}
+	listRules := make([]*Rules, 0, len(doc.RulesList))
+	for index, item := range doc.RulesList {
+		at := []interface{}{"rulesList", index}
+		if item == nil {
+			return nil, &documentError{path: at, err: fmt.Errorf("rulesList[%d]: empty rules", index)}
+		}
+		if item.Version != 0 || item.Kind != EmptyStr {
+			return nil, &documentError{path: at, err: fmt.Errorf("rulesList[%d]: nested rules should not have version or kind", index)}
+		}
+		rs, err := item.build()
+		if err != nil {
+			if e, ok := err.(*documentError); ok {
+				at = append(at, e.path...)
+			}
+			return nil, &documentError{path: at, err: fmt.Errorf("rulesList[%d]: %s", index, err.Error())}
+		}
+		listRules = append(listRules, rs)
+	}
+	// 不经NewRulesList，保留未命名的Rules
+	return &RulesList{RulesList: listRules, Name: doc.Name, Msg: doc.Msg}, nil
+}
+
+// build 按文档构造Rules
+func (doc *rulesDocument) build() (*Rules, error) {
+	if doc.Rules == nil {
+		return nil, &documentError{path: []interface{}{"rules"}, err: fmt.Errorf("missing rules")}
+	}
+	for index, rule := range doc.Rules {
+		if rule == nil {
+			return nil, &documentError{path: []interface{}{"rules", index}, err: fmt.Errorf("rules[%d]: empty rule", index)}
+		}
+	}
+	for _, rule := range doc.Rules {
+		rule.Val = normalizeNumbers(rule.Val)
+	}
+	rs, err := NewRulesWithArrayAndLogic(doc.Rules, doc.Logic)
+	if err != nil {
+		return nil, &documentError{path: doc.locate(), err: err}
+	}
+	rs.Name, rs.Msg, rs.Val, rs.Msgs = doc.Name, doc.Msg, normalizeNumbers(doc.Val), doc.Msgs
+	return rs, nil
+}
+
+// locate 构造失败的位置：第一条key或值不合法的子规则，都合法时是逻辑表达式
+func (doc *rulesDocument) locate() []interface{} {
+	for index, rule := range doc.Rules {
+		if err := (&Rules{Rules: []*Rule{rule}}).validKeys(); err != nil {
+			return []interface{}{"rules", index}
+		}
+		if _, err := rule.compile(); err != nil {
+			return []interface{}{"rules", index}
+		}
+	}
+	return []interface{}{"logic"}
+}
+
+// setFrom 用新构造的Rules替换内容，清空旧的取值计划
+func (rs *Rules) setFrom(built *Rules) {
+	rs.Rules, rs.Logic, rs.Name, rs.Msg, rs.Val, rs.Msgs = built.Rules, built.Logic, built.Name, built.Msg, built.Val, built.Msgs
+	rs.plans.Store(new(sync.Map))
+}
+
+// decodeDocument 严格解码，未知字段视为错误
+func decodeDocument(data []byte, doc interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	if err := dec.Decode(doc); err != nil {
+		return fmt.Errorf("invalid rules document: %s", err.Error())
+	}
+	if dec.More() {
+		return fmt.Errorf("invalid rules document: unexpected data after document")
+	}
+	return nil
+}
+
+// normalizeNumbers 把json.Number转为与json.Unmarshal一致的float64，超出float64精确范围的整数保留为json.Number精确比较
+func normalizeNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, ok := new(big.Int).SetString(t.String(), 10); ok && i.BitLen() > 53 {
+			return t
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return t
+		}
+		return f
+	case []interface{}:
+		for i := range t {
+			t[i] = normalizeNumbers(t[i])
+		}
+	case map[string]interface{}:
+		for k := range t {
+			t[k] = normalizeNumbers(t[k])
+		}
+	}
+	return v
+}
+
+func checkDocument(version int, kind, expect string) error {
+	if version == 0 {
+		return &documentError{err: fmt.Errorf("invalid rules document: missing version")}
+	}
+	if version > DocumentVersion {
+		return &documentError{path: []interface{}{"version"}, err: fmt.Errorf("invalid rules document: unsupported version %d", version)}
+	}
+	if kind != expect {
+		return &documentError{path: []interface{}{"kind"}, err: fmt.Errorf("invalid rules document: kind should be %q, got %q", expect, kind)}
+	}
+	return nil
+}
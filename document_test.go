@@ -0,0 +1,153 @@
+package ruler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRules(t *testing.T) {
+	doc := []byte(`{"version": 1, "kind": "rules", "name": "student", "msg": "not a good student", "logic": "1 and (2 or 3)", "val": {"level": 3},
+	"rules": [
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match"},
+	{"op": "in", "key": "Sex", "val": ["male", "female"], "id": 2},
+	{"op": "between", "key": "Score.Math", "val": "[90, ]", "id": 3}
+	]}`)
+	rs, err := LoadRules(doc)
+	assert.Nil(t, err)
+	assert.Equal(t, "student", rs.Name)
+	assert.Equal(t, "not a good student", rs.Msg)
+	assert.Equal(t, map[string]interface{}{"level": float64(3)}, rs.Val)
+	assert.Equal(t, 3, len(rs.Rules))
+
+	fit, _ := rs.FitWithMap(map[string]interface{}{"Grade": 3, "Sex": "male", "Score": map[string]interface{}{"Math": 60}})
+	assert.True(t, fit)
+
+	// 序列化后再读取，内容不变
+	data, err := json.Marshal(rs)
+	assert.Nil(t, err)
+	again, err := LoadRules(data)
+	assert.Nil(t, err)
+	dataAgain, err := json.Marshal(again)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(data), string(dataAgain))
+	assert.Equal(t, rs.Logic, again.Logic)
+	assert.Equal(t, rs.Val, again.Val)
+
+	var decoded Rules
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, rs.Rules, decoded.Rules)
+}
+
+func TestLoadRules_Errors(t *testing.T) {
+	cases := map[string]string{
+		`[{"op": "=", "key": "A", "val": 1, "id": 1}]`:                                                               "invalid rules document",
+		`{"kind": "rules", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]}`:                                   "missing version",
+		`{"version": 2, "kind": "rules", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]}`:                     "unsupported version 2",
+		`{"version": 1, "kind": "rulesList", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]}`:                 `kind should be "rules"`,
+		`{"version": 1, "kind": "rules"}`:                                                                            "missing rules",
+		`{"version": 1, "kind": "rules", "rules": [null]}`:                                                           "rules[0]: empty rule",
+		`{"version": 1, "kind": "rules", "logic": "1 and 3", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]}`: "invalid logic expression",
+		`{"version": 1, "kind": "rules", "rules": [{"op": "between", "key": "A", "val": "[3, 1]", "id": 1}]}`:        "rule 1: interval",
+		`{"version": 1, "kind": "rules", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1, "note": "x"}]}`:        "unknown field",
+		`{"version": 1, "kind": "rules", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]} {}`:                  "unexpected data",
+	}
+	for doc, expect := range cases {
+		_, err := LoadRules([]byte(doc))
+		if assert.NotNil(t, err, doc) {
+			assert.Contains(t, err.Error(), expect, doc)
+		}
+	}
+}
+
+func TestLoadRulesList(t *testing.T) {
+	doc := []byte(`{"version": 1, "kind": "rulesList", "name": "levels", "rulesList": [
+	{"name": "gold", "logic": "1 and 2", "val": "gold", "rules": [{"op": ">=", "key": "Score", "val": 90, "id": 1}, {"op": "=", "key": "Vip", "val": 1, "id": 2}]},
+	{"rules": [{"op": ">=", "key": "Score", "val": 60, "id": 1}]}
+	]}`)
+	rst, err := LoadRulesList(doc)
+	assert.Nil(t, err)
+	assert.Equal(t, "levels", rst.Name)
+	assert.Equal(t, 2, len(rst.RulesList))
+	// 未命名的Rules保持未命名
+	assert.Equal(t, EmptyStr, rst.RulesList[1].Name)
+
+	type Member struct {
+		Score int
+		Vip   int
+	}
+	assert.Equal(t, "gold", rst.Fit(&Member{Score: 95, Vip: 1}).Val)
+	assert.Same(t, rst.RulesList[1], rst.Fit(&Member{Score: 95}))
+
+	data, err := json.Marshal(rst)
+	assert.Nil(t, err)
+	again, err := LoadRulesList(data)
+	assert.Nil(t, err)
+	dataAgain, err := json.Marshal(again)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(data), string(dataAgain))
+
+	_, err = LoadRulesList([]byte(`{"version": 1, "kind": "rulesList", "rulesList": [{"rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]}, {"rules": [{"op": "regex", "key": "A", "val": 1, "id": 1}]}]}`))
+	assert.Nil(t, err)
+	_, err = LoadRulesList([]byte(`{"version": 1, "kind": "rulesList", "rulesList": [{"rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]}, {"logic": "1 or 2", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]}]}`))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "rulesList[1]: ")
+	}
+	_, err = LoadRulesList([]byte(`{"version": 1, "kind": "rulesList", "rulesList": [{"version": 1, "rules": [{"op": "=", "key": "A", "val": 1, "id": 1}]}]}`))
+	assert.NotNil(t, err)
+}
+
+func TestLoadRules_RoundTrip(t *testing.T) {
+	// 超出float64精确范围的整数不丢失精度
+	doc := []byte(`{"version": 1, "kind": "rules", "val": {"limit": 9007199254740993}, "rules": [
+	{"op": "=", "key": "UserID", "val": 9007199254740993, "id": 1},
+	{"op": "in", "key": "Tag", "val": [9223372036854775807, 1.5], "id": 2}
+	]}`)
+	rs, err := LoadRules(doc)
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), rs.Rules[0].Val)
+	assert.Equal(t, []interface{}{json.Number("9223372036854775807"), 1.5}, rs.Rules[1].Val)
+	assert.Equal(t, map[string]interface{}{"limit": json.Number("9007199254740993")}, rs.Val)
+	fit, _, err := rs.FitJSON([]byte(`{"UserID": 9007199254740993, "Tag": 9223372036854775807}`))
+	assert.Nil(t, err)
+	assert.True(t, fit)
+	fit, _, err = rs.FitJSON([]byte(`{"UserID": 9007199254740992, "Tag": 1.5}`))
+	assert.Nil(t, err)
+	assert.False(t, fit)
+	data, err := json.Marshal(rs)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"val":9007199254740993`)
+	again, err := LoadRules(data)
+	assert.Nil(t, err)
+	assert.Equal(t, rs.Rules[0].Val, again.Rules[0].Val)
+
+	// 普通数字与NewRulesWithJSONAndLogic一致
+	rs, err = LoadRules([]byte(`{"version": 1, "kind": "rules", "rules": [{"op": "=", "key": "A", "val": 3, "id": 1}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, float64(3), rs.Rules[0].Val)
+
+	// 空的Rules
+	data, err = json.Marshal(&Rules{})
+	assert.Nil(t, err)
+	empty, err := LoadRules(data)
+	assert.Nil(t, err)
+	assert.Empty(t, empty.Rules)
+	fit, _ = empty.FitWithMap(map[string]interface{}{})
+	assert.True(t, fit)
+
+	// 未命名的Rules
+	rst := &RulesList{RulesList: []*Rules{
+		{Name: "gold", Rules: []*Rule{{Op: "=", Key: "A", Val: float64(1), ID: 1}}},
+		{Rules: []*Rule{{Op: "=", Key: "A", Val: float64(2), ID: 1}}},
+	}}
+	data, err = json.Marshal(rst)
+	assert.Nil(t, err)
+	loaded, err := LoadRulesList(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "gold", loaded.RulesList[0].Name)
+	assert.Equal(t, EmptyStr, loaded.RulesList[1].Name)
+	dataAgain, err := json.Marshal(loaded)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(data), string(dataAgain))
+}
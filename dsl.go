@@ -0,0 +1,521 @@
+package ruler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/**
+  规则的文本写法，子规则和逻辑表达式写在一起：
+  Grade = 3 @msg("Grade not match") and not Sex = "male" and (Score.Math >= 90 or Score.Physic >= 90)
+  1. 子规则为 key 算符 值，值为json字面量；empty、exists等不需要值的算符省略值
+  2. key含空白、括号外的算符字符或与and/or/not同名时用反引号括起，如 `Store.Lat, Store.Lon` georadius {...}
+  3. 子规则后可加注解：@msg("...")为负提示，@msgs({"zh-CN": "..."})为按语言的负提示，@passMsg、@passMsgs为正提示，
+     @severity("warning")为级别，@id(5)指定ID，未指定的按出现顺序从1起自动分配
+  4. and、or、not和括号的含义与逻辑表达式一致，#到行尾为注释
+*/
+
+// dslSymbolOps 符号算符，按长度从长到短匹配
+var dslSymbolOps = []string{">=", "<=", "!=", "!@", "@@", "^$", "<<", "=", ">", "<", "@"}
+
+// dslAtom 文本中的一条子规则
+type dslAtom struct {
+	rule  *Rule
+	hasID bool
+	pos   int
+}
+
+// dslParser 文本的递归下降解析，logic中的子规则以-1-下标占位，分配ID后替换
+type dslParser struct {
+	src   string
+	pos   int
+	atoms []*dslAtom
+	logic []int
+}
+
+const (
+	dslAnd = iota
+	dslOr
+	dslNot
+	dslOpen
+	dslClose
+)
+
+var dslLogicWords = []string{"and", "or", "not", "(", ")"}
+
+// NewRulesWithDSL 用文本构造Rules，如 Grade = 3 and (Score.Math >= 90 or Score.Physic >= 90)
+func NewRulesWithDSL(dsl string) (*Rules, error) {
+	p := &dslParser{src: dsl}
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf(p.pos, "empty rules")
+	}
+	if err := p.parseOr(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf(p.pos, "expect and, or or end of rules")
+	}
+	rules, err := p.assignIDs()
+	if err != nil {
+		return nil, err
+	}
+	logic := make([]string, 0, len(p.logic))
+	for _, token := range p.logic {
+		if token < 0 {
+			logic = append(logic, strconv.Itoa(rules[-1-token].ID))
+		} else {
+			logic = append(logic, dslLogicWords[token])
+		}
+	}
+	return NewRulesWithArrayAndLogic(rules, strings.Join(logic, Space))
+}
+
+// errorf 错误信息带行列号
+func (p *dslParser) errorf(pos int, format string, args ...interface{}) error {
+	line := strings.Count(p.src[:pos], "\n") + 1
+	column := pos - strings.LastIndexByte(p.src[:pos], '\n')
+	return fmt.Errorf("dsl: line %d, column %d: %s", line, column, fmt.Sprintf(format, args...))
+}
+
+// skipSpace 跳过空白和注释
+func (p *dslParser) skipSpace() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// keyword 当前位置是否是关键字，是则跳过
+func (p *dslParser) keyword(word string) bool {
+	end := p.pos + len(word)
+	if end > len(p.src) || !strings.EqualFold(p.src[p.pos:end], word) {
+		return false
+	}
+	if end < len(p.src) && !isDSLSeparator(p.src[end]) {
+		return false
+	}
+	p.pos = end
+	p.skipSpace()
+	return true
+}
+
+func isDSLSeparator(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')' || c == '#'
+}
+
+func (p *dslParser) parseOr() error {
+	if err := p.parseAnd(); err != nil {
+		return err
+	}
+	for p.keyword("or") {
+		p.logic = append(p.logic, dslOr)
+		if err := p.parseAnd(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *dslParser) parseAnd() error {
+	if err := p.parseUnary(); err != nil {
+		return err
+	}
+	for p.keyword("and") {
+		p.logic = append(p.logic, dslAnd)
+		if err := p.parseUnary(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *dslParser) parseUnary() error {
+	if p.keyword("not") {
+		p.logic = append(p.logic, dslNot)
+		return p.parseUnary()
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '(' {
+		start := p.pos
+		p.pos++
+		p.skipSpace()
+		p.logic = append(p.logic, dslOpen)
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		if p.pos >= len(p.src) || p.src[p.pos] != ')' {
+			return p.errorf(start, "unclosed '('")
+		}
+		p.pos++
+		p.skipSpace()
+		p.logic = append(p.logic, dslClose)
+		return nil
+	}
+	return p.parseAtom()
+}
+
+// parseAtom 解析 key 算符 值 注解
+func (p *dslParser) parseAtom() error {
+	atom := &dslAtom{rule: &Rule{}, pos: p.pos}
+	key, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	atom.rule.Key = key
+	p.skipSpace()
+	if atom.rule.Op, err = p.parseOp(); err != nil {
+		return err
+	}
+	p.skipSpace()
+	if !atom.rule.testsPresence() {
+		if atom.rule.Val, err = p.parseValue(); err != nil {
+			return err
+		}
+		p.skipSpace()
+	}
+	for p.pos < len(p.src) && p.src[p.pos] == '@' {
+		if err := p.parseAnnotation(atom); err != nil {
+			return err
+		}
+		p.skipSpace()
+	}
+	p.logic = append(p.logic, -1-len(p.atoms))
+	p.atoms = append(p.atoms, atom)
+	return nil
+}
+
+// parseKey 反引号括起的key，或括号外遇到空白、括号、算符字符为止的key
+func (p *dslParser) parseKey() (string, error) {
+	start := p.pos
+	if p.pos >= len(p.src) {
+		return EmptyStr, p.errorf(p.pos, "expect key")
+	}
+	if p.src[p.pos] == '`' {
+		end := strings.IndexByte(p.src[p.pos+1:], '`')
+		if end < 0 {
+			return EmptyStr, p.errorf(start, "unclosed '`'")
+		}
+		p.pos += end + 2
+		return p.src[start+1 : p.pos-1], nil
+	}
+	depth := 0
+	var quote byte
+	for ; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		if quote != 0 {
+			if c == '\\' {
+				p.pos++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if depth > 0 {
+			switch c {
+			case '"', '\'':
+				quote = c
+			case '[':
+				depth++
+			case ']':
+				depth--
+			}
+			continue
+		}
+		if c == '[' {
+			depth++
+			continue
+		}
+		if isDSLSeparator(c) || strings.IndexByte("=!<>@^", c) >= 0 {
+			break
+		}
+	}
+	if p.pos == start {
+		return EmptyStr, p.errorf(start, "expect key")
+	}
+	return p.src[start:p.pos], nil
+}
+
+// parseOp 符号算符，或字母开头可带=<>的算符，如 in、ver>=
+func (p *dslParser) parseOp() (string, error) {
+	start := p.pos
+	for _, op := range dslSymbolOps {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			return op, nil
+		}
+	}
+	for p.pos < len(p.src) && (p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z' || p.src[p.pos] >= 'A' && p.src[p.pos] <= 'Z') {
+		p.pos++
+	}
+	for p.pos < len(p.src) && p.pos > start && strings.IndexByte("=<>", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	op := strings.ToLower(p.src[start:p.pos])
+	if op == EmptyStr {
+		return EmptyStr, p.errorf(start, "expect operator")
+	}
+	_, isDisplay := operatorValSchemas[op]
+	_, isAlias := operatorAliases[op]
+	if !isDisplay && !isAlias {
+		return EmptyStr, p.errorf(start, "unknown operator %q", op)
+	}
+	return op, nil
+}
+
+// parseValue 解析一个json字面量，数字与json文档一致，见normalizeNumbers
+func (p *dslParser) parseValue() (interface{}, error) {
+	if p.pos >= len(p.src) {
+		return nil, p.errorf(p.pos, "expect value")
+	}
+	dec := json.NewDecoder(strings.NewReader(p.src[p.pos:]))
+	dec.UseNumber()
+	var val interface{}
+	if err := dec.Decode(&val); err != nil {
+		return nil, p.errorf(p.pos, "invalid value: %s", err.Error())
+	}
+	end := p.pos + int(dec.InputOffset())
+	if end < len(p.src) && !isDSLSeparator(p.src[end]) && p.src[end] != '@' {
+		return nil, p.errorf(end, "expect space after value")
+	}
+	p.pos = end
+	return normalizeNumbers(val), nil
+}
+
+// parseAnnotation 解析 @msg("...")、@msgs({...})、@passMsg("...")、@passMsgs({...})、@severity("...") 或 @id(n)
+func (p *dslParser) parseAnnotation(atom *dslAtom) error {
+	start := p.pos
+	p.pos++
+	nameEnd := strings.IndexByte(p.src[p.pos:], '(')
+	if nameEnd < 0 {
+		return p.errorf(start, "expect @msg(...) or @id(...)")
+	}
+	name := p.src[p.pos : p.pos+nameEnd]
+	p.pos += nameEnd + 1
+	p.skipSpace()
+	val, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != ')' {
+		return p.errorf(start, "unclosed annotation @%s", name)
+	}
+	p.pos++
+	switch name {
+	case "msg", "passMsg", "severity":
+		msg, ok := val.(string)
+		if !ok {
+			return p.errorf(start, "@%s should be a string", name)
+		}
+		switch name {
+		case "msg":
+			atom.rule.Msg = msg
+		case "passMsg":
+			atom.rule.PassMsg = msg
+		default:
+			atom.rule.Severity = Severity(msg)
+		}
+	case "msgs", "passMsgs":
+		msgs, ok := val.(map[string]interface{})
+		if !ok {
+			return p.errorf(start, "@%s should be an object of locale and msg", name)
+		}
+		localized := make(map[string]string, len(msgs))
+		for locale, o := range msgs {
+			if localized[locale], ok = o.(string); !ok {
+				return p.errorf(start, "@%s should be an object of locale and msg", name)
+			}
+		}
+		if name == "msgs" {
+			atom.rule.Msgs = localized
+		} else {
+			atom.rule.PassMsgs = localized
+		}
+	case "id":
+		id, ok := val.(float64)
+		if !ok || id <= 0 || id != float64(int(id)) {
+			return p.errorf(start, "@id should be a positive integer")
+		}
+		atom.rule.ID, atom.hasID = int(id), true
+	default:
+		return p.errorf(start, "unknown annotation @%s", name)
+	}
+	return nil
+}
+
+// assignIDs 未指定ID的子规则按出现顺序分配未被占用的最小ID
+func (p *dslParser) assignIDs() ([]*Rule, error) {
+	used := make(map[int]bool)
+	for _, atom := range p.atoms {
+		if !atom.hasID {
+			continue
+		}
+		if used[atom.rule.ID] {
+			return nil, p.errorf(atom.pos, "duplicate @id(%d)", atom.rule.ID)
+		}
+		used[atom.rule.ID] = true
+	}
+	rules := make([]*Rule, 0, len(p.atoms))
+	next := 1
+	for _, atom := range p.atoms {
+		if !atom.hasID {
+			for used[next] {
+				next++
+			}
+			atom.rule.ID = next
+			used[next] = true
+		}
+		rules = append(rules, atom.rule)
+	}
+	return rules, nil
+}
+
+// DSL Rules转为文本，没有逻辑表达式时子规则以and连接；有子规则不在逻辑表达式中时报错，避免转换后丢失
+func (rs *Rules) DSL() (string, error) {
+	byID := make(map[int]*Rule, len(rs.Rules))
+	for _, rule := range rs.Rules {
+		byID[rule.ID] = rule
+	}
+	var tokens []string
+	if rs.Logic == EmptyStr {
+		for i, rule := range rs.Rules {
+			if i > 0 {
+				tokens = append(tokens, "and")
+			}
+			tokens = append(tokens, strconv.Itoa(rule.ID))
+		}
+	} else {
+		tokens = strings.Fields(rs.Logic)
+	}
+	// 子规则按出现顺序恰好是1、2、3...时省略@id
+	var order []int
+	seen := make(map[int]bool)
+	for _, token := range tokens {
+		if id, err := strconv.Atoi(token); err == nil {
+			order = append(order, id)
+			seen[id] = true
+		}
+	}
+	for _, rule := range rs.Rules {
+		if !seen[rule.ID] {
+			return EmptyStr, fmt.Errorf("rule %d not in logic", rule.ID)
+		}
+	}
+	withID := len(seen) != len(order)
+	for i, id := range order {
+		withID = withID || id != i+1
+	}
+
+	var buf strings.Builder
+	for i, token := range tokens {
+		if i > 0 && token != ")" && tokens[i-1] != "(" {
+			buf.WriteString(Space)
+		}
+		id, err := strconv.Atoi(token)
+		if err != nil {
+			buf.WriteString(token)
+			continue
+		}
+		rule, ok := byID[id]
+		if !ok {
+			return EmptyStr, fmt.Errorf("rule %d in logic not found", id)
+		}
+		atom, err := rule.dsl(withID)
+		if err != nil {
+			return EmptyStr, fmt.Errorf("rule %d: %s", id, err.Error())
+		}
+		buf.WriteString(atom)
+	}
+	return buf.String(), nil
+}
+
+// dsl 子规则转为文本，别名算符转为展示用的名称
+func (r *Rule) dsl(withID bool) (string, error) {
+	var buf strings.Builder
+	key, err := dslKey(r.Key)
+	if err != nil {
+		return EmptyStr, err
+	}
+	buf.WriteString(key)
+	op := r.Op
+	if display, ok := operatorAliases[op]; ok {
+		op = display
+	}
+	buf.WriteString(Space + op)
+	if !r.testsPresence() {
+		val, err := dslLiteral(r.Val)
+		if err != nil {
+			return EmptyStr, err
+		}
+		buf.WriteString(Space + val)
+	}
+	if r.Msg != EmptyStr {
+		msg, _ := dslLiteral(r.Msg)
+		buf.WriteString(" @msg(" + msg + ")")
+	}
+	if len(r.Msgs) > 0 {
+		msgs, _ := dslLiteral(r.Msgs)
+		buf.WriteString(" @msgs(" + msgs + ")")
+	}
+	if r.PassMsg != EmptyStr {
+		msg, _ := dslLiteral(r.PassMsg)
+		buf.WriteString(" @passMsg(" + msg + ")")
+	}
+	if len(r.PassMsgs) > 0 {
+		msgs, _ := dslLiteral(r.PassMsgs)
+		buf.WriteString(" @passMsgs(" + msgs + ")")
+	}
+	if r.Severity != EmptyStr {
+		severity, _ := dslLiteral(string(r.Severity))
+		buf.WriteString(" @severity(" + severity + ")")
+	}
+	if withID {
+		buf.WriteString(" @id(" + strconv.Itoa(r.ID) + ")")
+	}
+	return buf.String(), nil
+}
+
+// dslKey 不能按原样解析的key用反引号括起
+func dslKey(key string) (string, error) {
+	p := &dslParser{src: key}
+	if parsed, err := p.parseKey(); err == nil && parsed == key && !isDSLKeyword(key) {
+		return key, nil
+	}
+	if key == EmptyStr || strings.ContainsRune(key, '`') {
+		return EmptyStr, fmt.Errorf("key %q can not be written in dsl", key)
+	}
+	return "`" + key + "`", nil
+}
+
+func isDSLKeyword(key string) bool {
+	for _, word := range []string{"and", "or", "not"} {
+		if strings.EqualFold(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// dslLiteral 值转为json字面量，不转义html字符
+func dslLiteral(val interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return EmptyStr, err
+	}
+	return strings.TrimRightFunc(buf.String(), unicode.IsSpace), nil
+}
@@ -0,0 +1,127 @@
+package ruler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRulesWithDSL(t *testing.T) {
+	rs, err := NewRulesWithDSL(`Grade = 3 @msg("Grade not match") and not Sex = "male"
+	# 任一科90分以上
+	and (Score.Math >= 90 or Score.Physic >= 90 @msg("Physic not so well"))`)
+	assert.Nil(t, err)
+	assert.Equal(t, "1 and not 2 and ( 3 or 4 )", rs.Logic)
+	assert.Equal(t, []*Rule{
+		{Op: "=", Key: "Grade", Val: float64(3), ID: 1, Msg: "Grade not match"},
+		{Op: "=", Key: "Sex", Val: "male", ID: 2},
+		{Op: ">=", Key: "Score.Math", Val: float64(90), ID: 3},
+		{Op: ">=", Key: "Score.Physic", Val: float64(90), ID: 4, Msg: "Physic not so well"},
+	}, stripCompiled(rs.Rules))
+
+	fit, msg := rs.FitWithMap(map[string]interface{}{"Grade": 2, "Sex": "female", "Score": map[string]interface{}{"Math": 95}})
+	assert.False(t, fit)
+	assert.Equal(t, "Grade not match", msg[1])
+
+	dsl, err := rs.DSL()
+	assert.Nil(t, err)
+	assert.Equal(t, `Grade = 3 @msg("Grade not match") and not Sex = "male" and (Score.Math >= 90 or Score.Physic >= 90 @msg("Physic not so well"))`, dsl)
+}
+
+func TestNewRulesWithDSL_Operators(t *testing.T) {
+	rs, err := NewRulesWithDSL("Tags intersect [\"a\", \"b\"] and Name nempty and `Store.Lat, Store.Lon` georadius {\"lat\": 31.2, \"lon\": 121.5, \"radius\": 1000} " +
+		"and Version ver>= \"1.2\" and Items[\"a b\"].Count between \"[1, 3)\" and Score gte 60 @id(9) and `and` exists")
+	assert.Nil(t, err)
+	ops := []string{}
+	ids := []int{}
+	for _, rule := range rs.Rules {
+		ops = append(ops, rule.Op)
+		ids = append(ids, rule.ID)
+	}
+	assert.Equal(t, []string{"intersect", "nempty", "georadius", "ver>=", "between", "gte", "exists"}, ops)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 9, 6}, ids)
+	assert.Equal(t, "Store.Lat, Store.Lon", rs.Rules[2].Key)
+	assert.Equal(t, `Items["a b"].Count`, rs.Rules[4].Key)
+
+	// 打印后再解析得到相同的规则
+	dsl, err := rs.DSL()
+	assert.Nil(t, err)
+	assert.Contains(t, dsl, "`Store.Lat, Store.Lon` georadius")
+	assert.Contains(t, dsl, "Score >= 60 @id(9)")
+	assert.Contains(t, dsl, "`and` exists @id(6)")
+	again, err := NewRulesWithDSL(dsl)
+	assert.Nil(t, err)
+	assert.Equal(t, rs.Logic, again.Logic)
+	assert.Equal(t, len(rs.Rules), len(again.Rules))
+	for i := range rs.Rules {
+		assert.Equal(t, rs.Rules[i].ID, again.Rules[i].ID)
+		assert.Equal(t, rs.Rules[i].Key, again.Rules[i].Key)
+		assert.Equal(t, rs.Rules[i].Val, again.Rules[i].Val)
+	}
+}
+
+func TestRules_DSL(t *testing.T) {
+	// 没有逻辑表达式时以and连接
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[{"op": "@", "key": "Sex", "val": "male,female", "id": 1}, {"op": "<", "key": "Age", "val": 18, "id": 2, "msg": "<18"}]`), "")
+	assert.Nil(t, err)
+	dsl, err := rs.DSL()
+	assert.Nil(t, err)
+	assert.Equal(t, `Sex in "male,female" and Age < 18 @msg("<18")`, dsl)
+
+	rs, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": true, "id": 3}, {"op": "=", "key": "B", "val": null, "id": 5}]`), "not (5 or 3)")
+	assert.Nil(t, err)
+	dsl, err = rs.DSL()
+	assert.Nil(t, err)
+	assert.Equal(t, `not (B = null @id(5) or A = true @id(3))`, dsl)
+
+	// 不在逻辑表达式中的子规则转换后会丢失，报错
+	rs, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": 1, "id": 1}, {"op": "=", "key": "B", "val": 2, "id": 2}]`), "1")
+	assert.Nil(t, err)
+	_, err = rs.DSL()
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "rule 2 not in logic", err.Error())
+	}
+
+	// 大整数与json文档一样精确保留
+	rs, err = NewRulesWithDSL(`ID in [9007199254740993, 1.5] and Grade = 3`)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{json.Number("9007199254740993"), 1.5}, rs.Rules[0].Val)
+	assert.Equal(t, float64(3), rs.Rules[1].Val)
+	fit, _ := rs.FitWithMap(map[string]interface{}{"ID": int64(9007199254740992), "Grade": 3})
+	assert.False(t, fit)
+	dsl, err = rs.DSL()
+	assert.Nil(t, err)
+	assert.Equal(t, `ID in [9007199254740993,1.5] and Grade = 3`, dsl)
+}
+
+func TestNewRulesWithDSL_Errors(t *testing.T) {
+	cases := map[string]string{
+		"":                                    "line 1, column 1: empty rules",
+		"Grade = 3 and":                       "column 14: expect key",
+		"Grade 3":                             "column 7: expect operator",
+		"Grade is 3":                          `column 7: unknown operator "is"`,
+		"Grade = ":                            "column 9: expect value",
+		"Grade = abc":                         "column 9: invalid value",
+		"(Grade = 3":                          "column 1: unclosed '('",
+		"Grade = 3 Sex = 1":                   "column 11: expect and, or or end of rules",
+		"Grade = 3\n  and Sex ~ 1":            "line 2, column 11: expect operator",
+		"Grade = 3 @note(1)":                  "unknown annotation @note",
+		"Grade = 3 @id(1) and Sex = 1 @id(1)": "duplicate @id(1)",
+		"Grade = 3 @id(0)":                    "@id should be a positive integer",
+		"Score between \"[3, 1]\"":            "rule 1: interval",
+	}
+	for dsl, expect := range cases {
+		_, err := NewRulesWithDSL(dsl)
+		if assert.NotNil(t, err, dsl) {
+			assert.Contains(t, err.Error(), expect, dsl)
+		}
+	}
+}
+
+func stripCompiled(rules []*Rule) []*Rule {
+	for _, rule := range rules {
+		rule.compiled = nil
+	}
+	return rules
+}
@@ -0,0 +1,212 @@
+package ruler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+  format算符的内置格式，val为格式名：
+  email、url、uuid、country（ISO 3166-1两位字母）、currency（ISO 4217）、
+  e164（E.164电话号码）、luhn（Luhn校验，如银行卡号）、iban、cnid（中国居民身份证号）
+  子规则没有msg时，不满足的提示为该格式的默认提示
+*/
+
+// formatChecker 内置格式的校验器
+type formatChecker struct {
+	name  string
+	msg   string
+	check func(s string) bool
+}
+
+var (
+	patternEmail = regexp.MustCompile("^[A-Za-z0-9.!#$%&'*+/=?^_`{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)+$")
+	patternUUID  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	patternE164  = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+	patternIBAN  = regexp.MustCompile(`^[A-Z]{2}\d{2}[A-Z0-9]{11,30}$`)
+	patternCNID  = regexp.MustCompile(`^[1-9]\d{16}[\dXx]$`)
+)
+
+// countryCodes ISO 3166-1 alpha-2
+var countryCodes = codeSet("AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ " +
+	"CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR " +
+	"GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP " +
+	"KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ " +
+	"NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW " +
+	"SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ " +
+	"UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW")
+
+// currencyCodes ISO 4217
+var currencyCodes = codeSet("AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BOV BRL BSD BTN BWP BYN BZD " +
+	"CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUC CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD " +
+	"HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL LYD " +
+	"MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR " +
+	"RON RSD RUB RWF SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS " +
+	"UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF XAG XAU XBA XBB XBC XBD XCD XCG XDR XOF XPD XPF XPT XSU XTS XUA XXX " +
+	"YER ZAR ZMW ZWG ZWL")
+
+// formatCheckers 内置格式，按格式名索引
+var formatCheckers = map[string]*formatChecker{
+	"email":    {name: "email", msg: "invalid email address", check: isEmail},
+	"url":      {name: "url", msg: "invalid URL", check: isURL},
+	"uuid":     {name: "uuid", msg: "invalid UUID", check: patternUUID.MatchString},
+	"country":  {name: "country", msg: "invalid ISO 3166-1 country code", check: countryCodes.has},
+	"currency": {name: "currency", msg: "invalid ISO 4217 currency code", check: currencyCodes.has},
+	"e164":     {name: "e164", msg: "invalid E.164 phone number", check: patternE164.MatchString},
+	"luhn":     {name: "luhn", msg: "failed Luhn checksum", check: isLuhn},
+	"iban":     {name: "iban", msg: "invalid IBAN", check: isIBAN},
+	"cnid":     {name: "cnid", msg: "invalid Chinese resident ID number", check: isCNID},
+}
+
+type codes map[string]struct{}
+
+func codeSet(list string) codes {
+	set := make(codes)
+	for _, code := range strings.Fields(list) {
+		set[code] = struct{}{}
+	}
+	return set
+}
+
+func (set codes) has(code string) bool {
+	_, ok := set[code]
+	return ok
+}
+
+// newFormatChecker 按格式名取校验器，未知格式在构造时报错
+func newFormatChecker(val interface{}) (*formatChecker, error) {
+	name, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("value should be a format name, got %T", val)
+	}
+	checker, ok := formatCheckers[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", name)
+	}
+	return checker, nil
+}
+
+func isEmail(s string) bool {
+	if len(s) > 254 {
+		return false
+	}
+	at := strings.LastIndexByte(s, '@')
+	return at > 0 && at <= 64 && patternEmail.MatchString(s)
+}
+
+func isURL(s string) bool {
+	if strings.ContainsAny(s, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != EmptyStr && u.Host != EmptyStr
+}
+
+// isLuhn Luhn校验，允许空格和连字符分隔
+func isLuhn(s string) bool {
+	s = strings.NewReplacer(" ", "", "-", "").Replace(s)
+	if len(s) < 2 {
+		return false
+	}
+	sum := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if (len(s)-1-i)%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// isIBAN 国家代码和校验位移到末尾，字母转为数字后模97余1
+func isIBAN(s string) bool {
+	s = strings.ToUpper(strings.ReplaceAll(s, " ", EmptyStr))
+	if !patternIBAN.MatchString(s) || !countryCodes.has(s[:2]) {
+		return false
+	}
+	remainder := 0
+	for _, c := range s[4:] + s[:4] {
+		if c >= 'A' {
+			remainder = (remainder*100 + int(c-'A'+10)) % 97
+		} else {
+			remainder = (remainder*10 + int(c-'0')) % 97
+		}
+	}
+	return remainder == 1
+}
+
+// isCNID 18位居民身份证号：出生日期有效，末位为GB 11643的校验码
+func isCNID(s string) bool {
+	if !patternCNID.MatchString(s) {
+		return false
+	}
+	if _, err := time.Parse("20060102", s[6:14]); err != nil {
+		return false
+	}
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(s[i]-'0') * w
+	}
+	return "10X98765432"[sum%11] == strings.ToUpper(s[17:])[0]
+}
+
+// formatInput 实际值转为待校验的字符串，整数按十进制
+func formatInput(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case json.Number:
+		return t.String(), true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	default:
+		return EmptyStr, false
+	}
+}
+
+// fitFormat format算符的匹配
+func (r *Rule) fitFormat(v interface{}) (result bool, handled bool) {
+	if r.Op != "format" {
+		return false, false
+	}
+	checker, ok := r.compiledVal().(*formatChecker)
+	if !ok {
+		return false, true
+	}
+	s, ok := formatInput(v)
+	return ok && checker.check(s), true
+}
+
+// tip 子规则的提示，不满足时没有msg则使用算符的默认提示，pass为true时不使用默认提示
+func (r *Rule) tip(pass bool) string {
+	if r.Msg != EmptyStr || pass {
+		return r.Msg
+	}
+	if r.Op == "format" {
+		if checker, ok := r.compiledVal().(*formatChecker); ok {
+			return checker.msg
+		}
+	}
+	return r.Msg
+}
@@ -0,0 +1,76 @@
+package ruler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatCheckers(t *testing.T) {
+	cases := map[string]map[string]bool{
+		"email":    {"a.b+c@example.com": true, "user@sub.example.co": true, "a@b": false, "@example.com": false, "a b@example.com": false, "a@-example.com": false},
+		"url":      {"https://example.com/path?q=1": true, "ftp://host": true, "example.com": false, "http://": false, "http://a b.com": false},
+		"uuid":     {"123e4567-e89b-12d3-a456-426614174000": true, "123e4567e89b12d3a456426614174000": false, "123e4567-e89b-12d3-a456-42661417400g": false},
+		"country":  {"CN": true, "US": true, "cn": false, "XX": false, "CHN": false},
+		"currency": {"CNY": true, "EUR": true, "ABC": false, "cny": false},
+		"e164":     {"+8613800138000": true, "+14155552671": true, "13800138000": false, "+0123": false, "+1234567890123456": false},
+		"luhn":     {"4111 1111 1111 1111": true, "4111-1111-1111-1112": false, "79927398713": true, "0": false, "abc": false},
+		"iban":     {"GB82 WEST 1234 5698 7654 32": true, "DE89370400440532013000": true, "GB82WEST12345698765433": false, "XX82WEST12345698765432": false},
+		"cnid":     {"11010519491231002X": true, "110105194912310021": false, "11010519491331002X": false, "1101051949123100": false},
+	}
+	for name, values := range cases {
+		checker, err := newFormatChecker(name)
+		if !assert.Nil(t, err, name) {
+			continue
+		}
+		for s, expect := range values {
+			assert.Equal(t, expect, checker.check(s), "%s %s", name, s)
+		}
+	}
+	_, err := newFormatChecker("Email")
+	assert.Nil(t, err)
+	_, err = newFormatChecker("zipcode")
+	assert.NotNil(t, err)
+	_, err = newFormatChecker(3)
+	assert.NotNil(t, err)
+}
+
+func TestRules_FitFormat(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "format", "key": "Email", "val": "email", "id": 1},
+	{"op": "format", "key": "Card", "val": "luhn", "id": 2, "msg": "bad card"},
+	{"op": "format", "key": "Phone", "val": "e164", "id": 3},
+	{"op": "format", "key": "RequestID", "val": "uuid", "id": 4}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	assert.Nil(t, err)
+	type Request struct {
+		Email     string
+		Card      int64
+		Phone     string
+		RequestID string
+	}
+	fit, msg := rs.Fit(&Request{Email: "a@example.com", Card: 79927398713, Phone: "+8613800138000", RequestID: "123e4567-e89b-12d3-a456-426614174000"})
+	assert.True(t, fit)
+	// 默认提示只用于不满足的子规则
+	assert.Equal(t, map[int]string{1: "", 2: "bad card", 3: "", 4: ""}, msg)
+	fit, msg = rs.Fit(&Request{Email: "a@", Card: 79927398710, Phone: "13800138000", RequestID: "123e4567-e89b-12d3-a456-426614174000"})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "invalid email address", 2: "bad card", 3: "invalid E.164 phone number"}, msg)
+
+	rs, err = NewRulesWithJSONAndLogic(jsonRules, "1 and 2 and 4")
+	assert.Nil(t, err)
+	fit, msg = rs.FitWithMap(map[string]interface{}{"Email": "a@example.com", "Card": json.Number("1"), "Phone": 8613800138000, "RequestID": "123e4567-e89b-12d3-a456-426614174000"})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "bad card"}, msg)
+
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "format", "key": "A", "val": "zipcode", "id": 9}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `rule 9: unknown format "zipcode"`)
+	}
+	_, err = Compile[typedStudent]([]byte(`[{"op": "format", "key": "Name", "val": "email", "id": 1}]`), "")
+	assert.Nil(t, err)
+	_, err = Compile[typedStudent]([]byte(`[{"op": "format", "key": "Score", "val": "email", "id": 1}]`), "")
+	assert.NotNil(t, err)
+}
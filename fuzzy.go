@@ -0,0 +1,255 @@
+package ruler
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+  模糊匹配算符，实际值与任一目标足够接近即满足：
+  1. levenshtein：编辑距离（插入、删除、替换）
+  2. damerau：编辑距离，相邻字符交换也算一次编辑
+  3. jarowinkler：Jaro-Winkler相似度
+  val为 {"targets": ["Vladimir Putin", "..."], "similarity": 0.9} 或 {"target": "...", "distance": 2}
+  similarity为0~1的最低相似度，distance为最大编辑距离（jarowinkler不支持），ignorecase为true时忽略大小写
+  编辑距离的相似度为 1 - 距离/较长串的长度
+*/
+
+// FuzzyMatch 模糊匹配的结果，FitAskVal的values中以此代替实际值
+type FuzzyMatch struct {
+	Value    interface{} // 实际值
+	Target   string      // 最接近的目标
+	Score    float64     // 与最接近目标的相似度
+	Distance int         // 与最接近目标的编辑距离，jarowinkler为-1
+	Matched  bool
+}
+
+// fuzzyTargets 构造时解析的目标和阈值
+type fuzzyTargets struct {
+	targets    []string
+	similarity float64
+	distance   int // 小于0表示按相似度判断
+	ignoreCase bool
+}
+
+// isFuzzyOp 是否是模糊匹配算符
+func isFuzzyOp(op string) bool {
+	return op == "levenshtein" || op == "damerau" || op == "jarowinkler"
+}
+
+// newFuzzyTargets 解析模糊匹配的规则值
+func newFuzzyTargets(op string, val interface{}) (*fuzzyTargets, error) {
+	val, err := decodeObjectVal(val)
+	if err != nil {
+		return nil, err
+	}
+	ft := &fuzzyTargets{distance: -1}
+	if target, ok := lookupPath([]string{"target"}, val); ok {
+		str, ok := target.(string)
+		if !ok {
+			return nil, fmt.Errorf("target should be a string, got %T", target)
+		}
+		ft.targets = append(ft.targets, str)
+	}
+	if targets, ok := lookupPath([]string{"targets"}, val); ok {
+		elements, ok := listElements(targets)
+		if !ok {
+			return nil, fmt.Errorf("targets should be a list, got %T", targets)
+		}
+		for _, o := range elements {
+			str, ok := o.(string)
+			if !ok {
+				return nil, fmt.Errorf("target %v of %T is not a string", o, o)
+			}
+			ft.targets = append(ft.targets, str)
+		}
+	}
+	if len(ft.targets) == 0 {
+		return nil, fmt.Errorf("value should have target or targets")
+	}
+	if ignoreCase, ok := lookupPath([]string{"ignorecase"}, val); ok {
+		if ft.ignoreCase, ok = ignoreCase.(bool); !ok {
+			return nil, fmt.Errorf("ignorecase should be a bool, got %T", ignoreCase)
+		}
+	}
+	if ft.ignoreCase {
+		for i, target := range ft.targets {
+			ft.targets[i] = strings.ToLower(target)
+		}
+	}
+	similarity, hasSimilarity := lookupPath([]string{"similarity"}, val)
+	distance, hasDistance := lookupPath([]string{"distance"}, val)
+	switch {
+	case hasSimilarity && hasDistance:
+		return nil, fmt.Errorf("value should have either similarity or distance")
+	case hasSimilarity:
+		f, ok := toFloat(similarity)
+		if !ok || f < 0 || f > 1 {
+			return nil, fmt.Errorf("similarity should be a number between 0 and 1")
+		}
+		ft.similarity = f
+	case hasDistance:
+		if op == "jarowinkler" {
+			return nil, fmt.Errorf("operator %q supports similarity only", op)
+		}
+		f, ok := toFloat(distance)
+		if !ok || f < 0 || f != float64(int(f)) {
+			return nil, fmt.Errorf("distance should be a non-negative integer")
+		}
+		ft.distance = int(f)
+	default:
+		return nil, fmt.Errorf("value should have similarity or distance")
+	}
+	return ft, nil
+}
+
+// fuzzyMatch 模糊匹配算符的结果，其他算符返回false
+func (r *Rule) fuzzyMatch(v interface{}) (*FuzzyMatch, bool) {
+	if !isFuzzyOp(r.Op) {
+		return nil, false
+	}
+	match := &FuzzyMatch{Value: v, Distance: -1}
+	ft, ok := r.compiledVal().(*fuzzyTargets)
+	if !ok {
+		return match, true
+	}
+	s, ok := formatInput(v)
+	if !ok {
+		return match, true
+	}
+	if ft.ignoreCase {
+		s = strings.ToLower(s)
+	}
+	for index, target := range ft.targets {
+		score, distance := fuzzyScore(r.Op, s, target)
+		better := score > match.Score
+		if ft.distance >= 0 {
+			better = distance < match.Distance || match.Distance < 0
+		}
+		if index == 0 || better {
+			match.Target, match.Score, match.Distance = target, score, distance
+		}
+	}
+	if ft.distance >= 0 {
+		match.Matched = match.Distance <= ft.distance
+	} else {
+		match.Matched = match.Score >= ft.similarity
+	}
+	return match, true
+}
+
+// fitFuzzy 模糊匹配算符的匹配
+func (r *Rule) fitFuzzy(v interface{}) (result bool, handled bool) {
+	match, ok := r.fuzzyMatch(v)
+	if !ok {
+		return false, false
+	}
+	return match.Matched, true
+}
+
+// fuzzyScore 相似度和编辑距离，jarowinkler的编辑距离为-1
+func fuzzyScore(op string, a, b string) (float64, int) {
+	if op == "jarowinkler" {
+		return jaroWinkler([]rune(a), []rune(b)), -1
+	}
+	ra, rb := []rune(a), []rune(b)
+	distance := editDistance(ra, rb, op == "damerau")
+	longest := maxInt(len(ra), len(rb))
+	if longest == 0 {
+		return 1, distance
+	}
+	return 1 - float64(distance)/float64(longest), distance
+}
+
+// editDistance Levenshtein距离，transpose为true时按OSA计算相邻交换
+func editDistance(a, b []rune, transpose bool) int {
+	// 三行滚动数组：前前行、前行、当前行
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if transpose && i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = minInt(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// jaroWinkler Jaro-Winkler相似度，公共前缀最多计4个字符，权重0.1
+func jaroWinkler(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	window := maxInt(len(a), len(b))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matchedA := make([]bool, len(a))
+	matchedB := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		for j := maxInt(0, i-window); j < minInt(len(b), i+window+1); j++ {
+			if !matchedB[j] && a[i] == b[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	transpositions, k := 0, 0
+	for i := range a {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[k] {
+			k++
+		}
+		if a[i] != b[k] {
+			transpositions++
+		}
+		k++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+	prefix := 0
+	for prefix < minInt(4, len(a), len(b)) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func minInt(first int, rest ...int) int {
+	for _, n := range rest {
+		if n < first {
+			first = n
+		}
+	}
+	return first
+}
+
+func maxInt(first int, rest ...int) int {
+	for _, n := range rest {
+		if n > first {
+			first = n
+		}
+	}
+	return first
+}
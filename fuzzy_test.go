@@ -0,0 +1,86 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b                 string
+		levenshtein, damerau int
+	}{
+		{"kitten", "sitting", 3, 3},
+		{"ca", "ac", 2, 1},
+		{"abcdef", "abdcef", 2, 1},
+		{"", "abc", 3, 3},
+		{"张三丰", "张三峰", 1, 1},
+		{"same", "same", 0, 0},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.levenshtein, editDistance([]rune(c.a), []rune(c.b), false), "%s %s", c.a, c.b)
+		assert.Equal(t, c.damerau, editDistance([]rune(c.a), []rune(c.b), true), "%s %s", c.a, c.b)
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	assert.InDelta(t, 0.961, jaroWinkler([]rune("MARTHA"), []rune("MARHTA")), 0.001)
+	assert.InDelta(t, 0.840, jaroWinkler([]rune("DWAYNE"), []rune("DUANE")), 0.001)
+	assert.InDelta(t, 0.813, jaroWinkler([]rune("DIXON"), []rune("DICKSONX")), 0.001)
+	assert.Equal(t, 1.0, jaroWinkler(nil, nil))
+	assert.Equal(t, 0.0, jaroWinkler([]rune("abc"), []rune("xyz")))
+}
+
+func TestRules_FitFuzzy(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "jarowinkler", "key": "Name", "val": {"targets": ["Vladimir Putin", "Kim Jong Un"], "similarity": 0.9, "ignorecase": true}, "id": 1, "msg": "sanctioned"},
+	{"op": "damerau", "key": "Company", "val": {"target": "Acme Corp", "distance": 2}, "id": 2, "msg": "acme"},
+	{"op": "levenshtein", "key": "City", "val": "{\"targets\": [\"Beijing\", \"Nanjing\"], \"similarity\": 0.8}", "id": 3, "msg": "city"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	assert.Nil(t, err)
+	type Party struct {
+		Name    string
+		Company string
+		City    string
+	}
+	fit, msg, values := rs.FitAskVal(&Party{Name: "vladimir putln", Company: "Amce Crop", City: "Bejing"})
+	assert.True(t, fit, msg)
+	name := values[1].(*FuzzyMatch)
+	assert.Equal(t, "vladimir putin", name.Target)
+	assert.Equal(t, "vladimir putln", name.Value)
+	assert.True(t, name.Score > 0.9 && name.Score < 1)
+	assert.Equal(t, -1, name.Distance)
+	company := values[2].(*FuzzyMatch)
+	assert.Equal(t, 2, company.Distance)
+	assert.True(t, company.Matched)
+	city := values[3].(*FuzzyMatch)
+	assert.Equal(t, "Beijing", city.Target)
+	assert.Equal(t, 1, city.Distance)
+
+	fit, msg, values = rs.FitWithMapAskVal(map[string]interface{}{"Name": "John Smith", "Company": "Acme Corporation", "City": "Nanjing"})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "sanctioned", 2: "acme"}, msg)
+	assert.Equal(t, "Nanjing", values[3].(*FuzzyMatch).Target)
+	assert.Equal(t, 1.0, values[3].(*FuzzyMatch).Score)
+
+	fit, _, values = rs.FitWithMapAskVal(map[string]interface{}{"Company": "Acme Corp", "City": "Beijing"})
+	assert.False(t, fit)
+	assert.Equal(t, &FuzzyMatch{Distance: -1}, values[1])
+
+	for _, val := range []string{
+		`{"targets": ["a"]}`,
+		`{"target": "a", "similarity": 1.5}`,
+		`{"target": "a", "similarity": 0.5, "distance": 1}`,
+		`{"target": 1, "distance": 1}`,
+		`{"targets": "a", "distance": 1}`,
+		`{"target": "a", "distance": 1.5}`,
+		`"not json"`,
+	} {
+		_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "levenshtein", "key": "A", "val": `+val+`, "id": 1}]`), "")
+		assert.NotNil(t, err, val)
+	}
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "jarowinkler", "key": "A", "val": {"target": "a", "distance": 1}, "id": 1}]`), "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,338 @@
+package ruler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/**
+  地理位置算符：
+  1. 位置取自一个key或逗号分隔的纬度、经度两个key，如 "Location" 或 "Store.Lat, Store.Lon"
+  2. 一个key时值可以是含lat/lon（latitude/longitude、lng）的对象、GeoJSON Point，或GeoJSON顺序的[经度, 纬度]
+  3. georadius：val为 {"lat": 31.23, "lon": 121.47, "radius": 5000}，radius单位为米，按球面大圆距离(haversine)计算
+  4. geopolygon：val为GeoJSON的Polygon、MultiPolygon或其Feature，构造时解析，支持内环（洞）
+*/
+
+// earthRadius 地球平均半径，单位米
+const earthRadius = 6371008.8
+
+// geoPoint 经纬度，单位为度
+type geoPoint struct {
+	lat, lon float64
+}
+
+// geoCircle 圆心和半径
+type geoCircle struct {
+	center geoPoint
+	radius float64
+}
+
+// geoPolygon 多边形，rings[0]为外环，其余为内环，每个环的点为[经度, 纬度]
+type geoPolygon struct {
+	rings                          [][][2]float64
+	minLon, minLat, maxLon, maxLat float64
+}
+
+// geoShape 多边形的并集
+type geoShape []*geoPolygon
+
+// latKeys lonKeys 对象中纬度、经度的候选键名
+var latKeys = []string{"lat", "Lat", "LAT", "latitude", "Latitude"}
+var lonKeys = []string{"lon", "Lon", "LON", "lng", "Lng", "longitude", "Longitude"}
+
+// isGeoOp 是否是地理位置算符
+func isGeoOp(op string) bool {
+	return op == "georadius" || op == "geopolygon"
+}
+
+// keyPaths 子规则引用的key，地理位置算符可以是逗号分隔的纬度、经度两个key
+func (r *Rule) keyPaths() []string {
+	if !isGeoOp(r.Op) {
+		return []string{r.Key}
+	}
+	keys := splitTopLevel(r.Key)
+	if len(keys) != 2 {
+		return []string{r.Key}
+	}
+	return keys
+}
+
+// splitTopLevel 按方括号、引号之外的逗号分隔key
+func splitTopLevel(key string) []string {
+	var keys []string
+	var quote rune
+	depth, start := 0, 0
+	runes := []rune(key)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if depth > 0 {
+				quote = c
+			}
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			keys = append(keys, strings.TrimSpace(string(runes[start:i])))
+			start = i + 1
+		}
+	}
+	return append(keys, strings.TrimSpace(string(runes[start:])))
+}
+
+// joinLatLon 两个key取到的纬度、经度合成一个位置对象
+func joinLatLon(lat interface{}, latOK bool, lon interface{}, lonOK bool) (interface{}, bool) {
+	if !latOK && !lonOK {
+		return nil, false
+	}
+	return map[string]interface{}{"lat": lat, "lon": lon}, latOK && lonOK
+}
+
+// toGeoPoint 实际值转为经纬度
+func toGeoPoint(v interface{}) (geoPoint, bool) {
+	if elements, ok := listElements(v); ok {
+		// GeoJSON顺序：[经度, 纬度]，可带海拔
+		if len(elements) != 2 && len(elements) != 3 {
+			return geoPoint{}, false
+		}
+		return newGeoPoint(elements[1], elements[0])
+	}
+	if t, _ := lookupPath([]string{"type"}, v); t == "Point" {
+		coordinates, _ := lookupPath([]string{"coordinates"}, v)
+		return toGeoPoint(coordinates)
+	}
+	lat, ok := lookupAny(latKeys, v)
+	if !ok {
+		return geoPoint{}, false
+	}
+	lon, ok := lookupAny(lonKeys, v)
+	if !ok {
+		return geoPoint{}, false
+	}
+	return newGeoPoint(lat, lon)
+}
+
+func lookupAny(keys []string, o interface{}) (interface{}, bool) {
+	for _, key := range keys {
+		if v, ok := lookupPath([]string{key}, o); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// newGeoPoint 校验经纬度的范围
+func newGeoPoint(lat, lon interface{}) (geoPoint, bool) {
+	p := geoPoint{}
+	var ok bool
+	if p.lat, ok = toFloat(lat); !ok || p.lat < -90 || p.lat > 90 {
+		return geoPoint{}, false
+	}
+	if p.lon, ok = toFloat(lon); !ok || p.lon < -180 || p.lon > 180 {
+		return geoPoint{}, false
+	}
+	return p, true
+}
+
+// toFloat 数字、json数字或数字字符串转为float64
+func toFloat(v interface{}) (float64, bool) {
+	var f float64
+	switch t := v.(type) {
+	case json.Number:
+		var err error
+		if f, err = t.Float64(); err != nil {
+			return 0, false
+		}
+	case string:
+		var err error
+		if f, err = strconv.ParseFloat(strings.TrimSpace(t), 64); err != nil {
+			return 0, false
+		}
+	default:
+		if !isNumber(v) {
+			return 0, false
+		}
+		f = formatNumber(v)
+	}
+	return f, !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// distance 两点间的大圆距离，单位米
+func (p geoPoint) distance(o geoPoint) float64 {
+	lat1, lat2 := p.lat*math.Pi/180, o.lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (o.lon - p.lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// decodeObjectVal 对象形式的规则值，也可以是其json串
+func decodeObjectVal(val interface{}) (interface{}, error) {
+	str, ok := val.(string)
+	if !ok {
+		return val, nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(str), &decoded); err != nil {
+		return nil, fmt.Errorf("invalid object value: %s", err.Error())
+	}
+	return decoded, nil
+}
+
+// newGeoCircle 解析georadius的规则值
+func newGeoCircle(val interface{}) (*geoCircle, error) {
+	val, err := decodeObjectVal(val)
+	if err != nil {
+		return nil, err
+	}
+	center, ok := toGeoPoint(val)
+	if !ok {
+		return nil, fmt.Errorf("value should have a valid lat and lon")
+	}
+	radius, _ := lookupPath([]string{"radius"}, val)
+	r, ok := toFloat(radius)
+	if !ok || r < 0 {
+		return nil, fmt.Errorf("value should have a non-negative radius in meters")
+	}
+	return &geoCircle{center: center, radius: r}, nil
+}
+
+// newGeoShape 解析GeoJSON的Polygon、MultiPolygon或Feature
+func newGeoShape(val interface{}) (geoShape, error) {
+	val, err := decodeObjectVal(val)
+	if err != nil {
+		return nil, err
+	}
+	typ, _ := lookupPath([]string{"type"}, val)
+	switch typ {
+	case "Feature":
+		geometry, _ := lookupPath([]string{"geometry"}, val)
+		return newGeoShape(geometry)
+	case "Polygon":
+		coordinates, _ := lookupPath([]string{"coordinates"}, val)
+		polygon, err := newGeoPolygon(coordinates)
+		if err != nil {
+			return nil, err
+		}
+		return geoShape{polygon}, nil
+	case "MultiPolygon":
+		coordinates, _ := lookupPath([]string{"coordinates"}, val)
+		polygons, ok := listElements(coordinates)
+		if !ok || len(polygons) == 0 {
+			return nil, fmt.Errorf("MultiPolygon should have polygons")
+		}
+		var shape geoShape
+		for _, o := range polygons {
+			polygon, err := newGeoPolygon(o)
+			if err != nil {
+				return nil, err
+			}
+			shape = append(shape, polygon)
+		}
+		return shape, nil
+	default:
+		return nil, fmt.Errorf("value should be a GeoJSON Polygon, MultiPolygon or Feature, got type %v", typ)
+	}
+}
+
+func newGeoPolygon(coordinates interface{}) (*geoPolygon, error) {
+	rings, ok := listElements(coordinates)
+	if !ok || len(rings) == 0 {
+		return nil, fmt.Errorf("polygon should have at least one ring")
+	}
+	polygon := &geoPolygon{minLon: 180, minLat: 90, maxLon: -180, maxLat: -90}
+	for _, o := range rings {
+		points, ok := listElements(o)
+		if !ok {
+			return nil, fmt.Errorf("ring should be a list of positions")
+		}
+		var ring [][2]float64
+		for _, position := range points {
+			p, ok := toGeoPoint(position)
+			if !ok {
+				return nil, fmt.Errorf("invalid position %v", position)
+			}
+			ring = append(ring, [2]float64{p.lon, p.lat})
+		}
+		// 闭合的环去掉重复的终点
+		if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+			ring = ring[:len(ring)-1]
+		}
+		if len(ring) < 3 {
+			return nil, fmt.Errorf("ring should have at least 3 distinct positions")
+		}
+		polygon.rings = append(polygon.rings, ring)
+	}
+	for _, p := range polygon.rings[0] {
+		polygon.minLon, polygon.maxLon = math.Min(polygon.minLon, p[0]), math.Max(polygon.maxLon, p[0])
+		polygon.minLat, polygon.maxLat = math.Min(polygon.minLat, p[1]), math.Max(polygon.maxLat, p[1])
+	}
+	return polygon, nil
+}
+
+// contains 点在外环内且不在任何内环内
+func (polygon *geoPolygon) contains(p geoPoint) bool {
+	if p.lon < polygon.minLon || p.lon > polygon.maxLon || p.lat < polygon.minLat || p.lat > polygon.maxLat {
+		return false
+	}
+	if !inRing(polygon.rings[0], p) {
+		return false
+	}
+	for _, hole := range polygon.rings[1:] {
+		if inRing(hole, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// inRing 射线法判断点是否在环内
+func inRing(ring [][2]float64, p geoPoint) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > p.lat) != (b[1] > p.lat) && p.lon < (b[0]-a[0])*(p.lat-a[1])/(b[1]-a[1])+a[0] {
+			in = !in
+		}
+	}
+	return in
+}
+
+func (shape geoShape) contains(p geoPoint) bool {
+	for _, polygon := range shape {
+		if polygon.contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// fitGeo 地理位置算符的匹配，实际值不是合法经纬度时不满足
+func (r *Rule) fitGeo(v interface{}) (result bool, handled bool) {
+	if !isGeoOp(r.Op) {
+		return false, false
+	}
+	p, ok := toGeoPoint(v)
+	if !ok {
+		return false, true
+	}
+	switch compiled := r.compiledVal().(type) {
+	case *geoCircle:
+		return p.distance(compiled.center) <= compiled.radius, true
+	case geoShape:
+		return compiled.contains(p), true
+	default:
+		return false, true
+	}
+}
@@ -0,0 +1,121 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 以人民广场附近为例的服务区，中间挖去一块
+const testServicePolygon = `{"type": "Polygon", "coordinates": [
+	[[121.40, 31.20], [121.55, 31.20], [121.55, 31.30], [121.40, 31.30], [121.40, 31.20]],
+	[[121.46, 31.24], [121.48, 31.24], [121.48, 31.26], [121.46, 31.26], [121.46, 31.24]]
+]}`
+
+func TestGeoPoint_Distance(t *testing.T) {
+	shanghai := geoPoint{lat: 31.2304, lon: 121.4737}
+	beijing := geoPoint{lat: 39.9042, lon: 116.4074}
+	assert.InDelta(t, 1067000, shanghai.distance(beijing), 3000)
+	assert.Equal(t, 0.0, shanghai.distance(shanghai))
+}
+
+func TestToGeoPoint(t *testing.T) {
+	expect := geoPoint{lat: 31.2, lon: 121.5}
+	for _, v := range []interface{}{
+		map[string]interface{}{"lat": 31.2, "lon": 121.5},
+		map[string]float64{"latitude": 31.2, "lng": 121.5},
+		map[string]interface{}{"Lat": "31.2", "Lon": "121.5"},
+		map[string]interface{}{"type": "Point", "coordinates": []interface{}{121.5, 31.2}},
+		[]float64{121.5, 31.2},
+	} {
+		p, ok := toGeoPoint(v)
+		assert.True(t, ok, "%v", v)
+		assert.Equal(t, expect, p, "%v", v)
+	}
+	for _, v := range []interface{}{nil, "31.2,121.5", []float64{1}, map[string]interface{}{"lat": 91, "lon": 0}, map[string]interface{}{"lat": 1}} {
+		_, ok := toGeoPoint(v)
+		assert.False(t, ok, "%v", v)
+	}
+}
+
+func TestGeoShape(t *testing.T) {
+	shape, err := newGeoShape(testServicePolygon)
+	assert.Nil(t, err)
+	assert.True(t, shape.contains(geoPoint{lat: 31.22, lon: 121.42}))
+	assert.False(t, shape.contains(geoPoint{lat: 31.25, lon: 121.47}))
+	assert.False(t, shape.contains(geoPoint{lat: 31.35, lon: 121.47}))
+
+	shape, err = newGeoShape(map[string]interface{}{"type": "Feature", "geometry": map[string]interface{}{
+		"type": "MultiPolygon", "coordinates": []interface{}{
+			[]interface{}{[]interface{}{[]interface{}{0, 0}, []interface{}{1, 0}, []interface{}{0, 1}}},
+			[]interface{}{[]interface{}{[]interface{}{10, 10}, []interface{}{11, 10}, []interface{}{11, 11}, []interface{}{10, 11}}},
+		}}})
+	assert.Nil(t, err)
+	assert.True(t, shape.contains(geoPoint{lat: 0.2, lon: 0.2}))
+	assert.True(t, shape.contains(geoPoint{lat: 10.5, lon: 10.5}))
+	assert.False(t, shape.contains(geoPoint{lat: 0.9, lon: 0.9}))
+
+	for _, val := range []interface{}{
+		`{"type": "Point", "coordinates": [0, 0]}`,
+		`{"type": "Polygon", "coordinates": [[[0, 0], [1, 1], [0, 0]]]}`,
+		`{"type": "Polygon", "coordinates": [[[0, 0], [1, 1], [500, 0]]]}`,
+		`{"type": "Polygon"}`,
+		`not json`,
+	} {
+		_, err = newGeoShape(val)
+		assert.NotNil(t, err, "%v", val)
+	}
+}
+
+func TestRules_FitGeo(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "georadius", "key": "Location", "val": {"lat": 31.2304, "lon": 121.4737, "radius": 5000}, "id": 1, "msg": "too far from store"},
+	{"op": "geopolygon", "key": "Address.Lat, Address.Lon", "val": ` + testServicePolygon + `, "id": 2, "msg": "out of service"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	assert.Nil(t, err)
+	type Point struct {
+		Lat float64
+		Lon float64
+	}
+	type Order struct {
+		Location Point
+		Address  *Point
+	}
+	fit, msg := rs.Fit(&Order{Location: Point{Lat: 31.25, Lon: 121.45}, Address: &Point{Lat: 31.22, Lon: 121.42}})
+	assert.True(t, fit, msg)
+	fit, msg = rs.Fit(&Order{Location: Point{Lat: 31.40, Lon: 121.45}, Address: &Point{Lat: 31.25, Lon: 121.47}})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "too far from store", 2: "out of service"}, msg)
+
+	fit, _, err = rs.FitJSON([]byte(`{"Location": {"type": "Point", "coordinates": [121.45, 31.25]}, "Address": {"Lat": 31.22, "Lon": 121.42}}`))
+	assert.Nil(t, err)
+	assert.True(t, fit)
+
+	fit, _, values := rs.FitWithMapAskVal(map[string]interface{}{"Location": []float64{121.45, 31.25}, "Address": map[string]interface{}{"Lat": 31.22}})
+	assert.False(t, fit)
+	assert.Equal(t, map[string]interface{}{"lat": 31.22, "lon": nil}, values[2])
+
+	truth, _, keys := rs.FitWithMapTernary(map[string]interface{}{"Location": []float64{121.45, 31.25}})
+	assert.Equal(t, TruthUnknown, truth)
+	assert.Equal(t, []string{"Address.Lat, Address.Lon"}, keys)
+
+	for _, val := range []string{`{"lat": 31, "lon": 121}`, `{"lat": 31, "lon": 200, "radius": 1}`, `"bad"`} {
+		_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "georadius", "key": "A", "val": `+val+`, "id": 1}]`), "")
+		assert.NotNil(t, err, val)
+	}
+
+	type typedOrder struct {
+		Location Point
+		Lat      float64
+		Lon      string
+		Name     string
+	}
+	_, err = Compile[typedOrder]([]byte(`[{"op": "georadius", "key": "Lat, Lon", "val": {"lat": 1, "lon": 1, "radius": 1}, "id": 1},
+	{"op": "geopolygon", "key": "Location", "val": `+testServicePolygon+`, "id": 2}]`), "")
+	assert.Nil(t, err)
+	_, err = Compile[typedOrder]([]byte(`[{"op": "georadius", "key": "Name", "val": {"lat": 1, "lon": 1, "radius": 1}, "id": 1}]`), "")
+	assert.NotNil(t, err)
+	_, err = Compile[typedOrder]([]byte(`[{"op": "georadius", "key": "Lat, Location", "val": {"lat": 1, "lon": 1, "radius": 1}, "id": 1}]`), "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,286 @@
+package ruler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/**
+  between的区间语法：
+  1. 开闭区间：[1, 3]、(1, 3]、[1, 3)、(1, 3)，缺省的端点表示无穷：[, 6]、(8, )
+  2. 无穷端点：-inf、+inf、inf、∞
+  3. 数字支持科学计数法：[1e-3, 2.5E2]
+  4. 日期：[2024-01-01, 2024-06-30T12:00:00+08:00)，支持RFC3339、2006-01-02、2006-01-02 15:04:05
+  5. 并集：[1,3] ∪ (5,9]，也可用U或|连接
+*/
+
+// patternDecimal 十进制数字，可带指数
+var patternDecimal = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+// dateLayouts 区间端点和实际值可用的日期格式
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+type boundKind int8
+
+const (
+	boundInf boundKind = iota
+	boundNumber
+	boundDate
+)
+
+// bound 区间端点
+type bound struct {
+	kind   boundKind
+	num    *big.Rat
+	float  float64
+	date   time.Time
+	closed bool
+}
+
+// interval 单个区间
+type interval struct {
+	lower, upper bound
+	isDate       bool
+}
+
+// intervalSet 区间的并集
+type intervalSet []*interval
+
+// parseIntervals 解析区间或区间的并集
+func parseIntervals(scope string) (intervalSet, error) {
+	var set intervalSet
+	rest := strings.TrimSpace(scope)
+	if rest == EmptyStr {
+		return nil, fmt.Errorf("empty interval")
+	}
+	for {
+		if rest == EmptyStr || (rest[0] != '[' && rest[0] != '(') {
+			return nil, fmt.Errorf("interval %q: expect '[' or '('", scope)
+		}
+		end := strings.IndexAny(rest, "])")
+		if end < 0 {
+			return nil, fmt.Errorf("interval %q: expect ']' or ')'", scope)
+		}
+		item, err := parseInterval(rest[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("interval %q: %s", scope, err.Error())
+		}
+		set = append(set, item)
+		rest = strings.TrimSpace(rest[end+1:])
+		if rest == EmptyStr {
+			break
+		}
+		sep := false
+		for _, s := range []string{"∪", "U", "u", "|"} {
+			if strings.HasPrefix(rest, s) {
+				rest = strings.TrimSpace(rest[len(s):])
+				sep = true
+				break
+			}
+		}
+		if !sep {
+			return nil, fmt.Errorf("interval %q: expect '∪' between intervals", scope)
+		}
+	}
+	for _, item := range set[1:] {
+		if item.isDate != set[0].isDate {
+			return nil, fmt.Errorf("interval %q: can not mix dates and numbers", scope)
+		}
+	}
+	return set, nil
+}
+
+// parseInterval 解析形如[a, b)的单个区间
+func parseInterval(s string) (*interval, error) {
+	parts := strings.Split(s[1:len(s)-1], ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%s should have exactly two bounds", s)
+	}
+	lower, err := parseBound(parts[0], s[0] == '[', false)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := parseBound(parts[1], s[len(s)-1] == ']', true)
+	if err != nil {
+		return nil, err
+	}
+	if lower.kind == boundInf && upper.kind == boundInf && strings.TrimSpace(parts[0]) == EmptyStr && strings.TrimSpace(parts[1]) == EmptyStr {
+		return nil, fmt.Errorf("%s has no bound", s)
+	}
+	if lower.kind != boundInf && upper.kind != boundInf && lower.kind != upper.kind {
+		return nil, fmt.Errorf("%s can not mix dates and numbers", s)
+	}
+	item := &interval{lower: lower, upper: upper, isDate: lower.kind == boundDate || upper.kind == boundDate}
+	if lower.kind != boundInf && upper.kind != boundInf && lower.compare(upper) > 0 {
+		return nil, fmt.Errorf("%s lower bound is greater than upper bound", s)
+	}
+	return item, nil
+}
+
+// parseBound 解析端点，空串或inf表示无穷
+func parseBound(s string, closed bool, isUpper bool) (bound, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case EmptyStr:
+		return bound{kind: boundInf}, nil
+	case "-inf", "-∞":
+		if isUpper {
+			return bound{}, fmt.Errorf("upper bound can not be %s", s)
+		}
+		return bound{kind: boundInf}, nil
+	case "+inf", "inf", "+∞", "∞":
+		if !isUpper {
+			return bound{}, fmt.Errorf("lower bound can not be %s", s)
+		}
+		return bound{kind: boundInf}, nil
+	}
+	if num, ok := parseDecimal(s); ok {
+		f, _ := num.Float64()
+		return bound{kind: boundNumber, num: num, float: f, closed: closed}, nil
+	}
+	if date, ok := parseDate(s); ok {
+		return bound{kind: boundDate, date: date, closed: closed}, nil
+	}
+	return bound{}, fmt.Errorf("invalid bound %q", s)
+}
+
+func parseDecimal(s string) (*big.Rat, bool) {
+	if !patternDecimal.MatchString(s) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// compare 比较同类端点
+func (b bound) compare(o bound) int {
+	if b.kind == boundDate {
+		return b.date.Compare(o.date)
+	}
+	return b.num.Cmp(o.num)
+}
+
+// contains 实际值是否落在任一区间中
+func (set intervalSet) contains(v interface{}) bool {
+	if len(set) == 0 {
+		return false
+	}
+	if set[0].isDate {
+		date, ok := toDate(v)
+		if !ok {
+			return false
+		}
+		for _, item := range set {
+			if item.containsDate(date) {
+				return true
+			}
+		}
+		return false
+	}
+	var num *big.Rat
+	var f float64
+	switch t := v.(type) {
+	case json.Number:
+		if num, _ = parseDecimal(t.String()); num == nil {
+			return false
+		}
+	case string:
+		if num, _ = parseDecimal(strings.TrimSpace(t)); num == nil {
+			return false
+		}
+	default:
+		if !isNumber(v) {
+			return false
+		}
+		f = formatNumber(v)
+		if math.IsNaN(f) {
+			return false
+		}
+	}
+	for _, item := range set {
+		if item.containsNumber(num, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsNumber num不为nil时精确比较，否则用float64比较
+func (item *interval) containsNumber(num *big.Rat, f float64) bool {
+	cmp := func(b bound) int {
+		if num != nil {
+			return num.Cmp(b.num)
+		}
+		switch {
+		case f < b.float:
+			return -1
+		case f > b.float:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return item.check(cmp)
+}
+
+func (item *interval) containsDate(date time.Time) bool {
+	return item.check(func(b bound) int {
+		return date.Compare(b.date)
+	})
+}
+
+// check 用实际值与端点的比较结果判断是否在区间内
+func (item *interval) check(cmp func(b bound) int) bool {
+	if item.lower.kind != boundInf {
+		c := cmp(item.lower)
+		if c < 0 || c == 0 && !item.lower.closed {
+			return false
+		}
+	}
+	if item.upper.kind != boundInf {
+		c := cmp(item.upper)
+		if c > 0 || c == 0 && !item.upper.closed {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case uint, uint8, uint16, uint32, uint64, int, int8, int16, int32, int64, float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// toDate 实际值转为时间：time.Time、*time.Time或日期字符串
+func toDate(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, false
+		}
+		return *t, true
+	case string:
+		return parseDate(strings.TrimSpace(t))
+	default:
+		return time.Time{}, false
+	}
+}
@@ -0,0 +1,82 @@
+package ruler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIntervals(t *testing.T) {
+	cases := map[string]map[interface{}]bool{
+		"(2.99,3]":                {3: true, 2.99: false, 3.0001: false},
+		"[, 6]":                   {-1e10: true, 6: true, 6.5: false},
+		"(8, )":                   {8: false, 1e10: true},
+		"(1,  3.1)":               {1: false, 3.1: false, 2: true},
+		"[1e-3, 2.5E2]":           {0.001: true, 250: true, 250.1: false},
+		"(-inf, 0) ∪ [10, +inf)":  {-5: true, 0: false, 5: false, 10: true},
+		"[1,3] U (5,9] | [20,20]": {3: true, 4: false, 5: false, 9: true, 20: true},
+		"[-∞, ∞]":                 {-1e300: true},
+		"[0.1, 0.3]":              {json.Number("0.30000000000000000001"): false, json.Number("0.3"): true, "0.2": true, " 0.25 ": true, "x": false, true: false, nil: false},
+	}
+	for scope, values := range cases {
+		set, err := parseIntervals(scope)
+		if !assert.Nil(t, err, scope) {
+			continue
+		}
+		for v, expect := range values {
+			assert.Equal(t, expect, set.contains(v), "%s %v", scope, v)
+		}
+	}
+
+	for _, scope := range []string{"", "[,]", "[1,2", "1,2]", "[1,2,3]", "[3,1]", "[a,1]", "[1,2] [3,4]", "[+inf, 1]", "[1, -inf]", "[1,2] ∪ [2024-01-01,]", "[1, 2024-01-01]", "[1x2, 3]"} {
+		_, err := parseIntervals(scope)
+		assert.NotNil(t, err, scope)
+	}
+}
+
+func TestParseIntervals_Dates(t *testing.T) {
+	set, err := parseIntervals("[2024-01-01, 2024-06-30T12:00:00+08:00) ∪ [2025-01-01 08:00:00, ]")
+	assert.Nil(t, err)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.True(t, set.contains(day))
+	assert.True(t, set.contains(&day))
+	assert.True(t, set.contains("2024-01-01"))
+	assert.False(t, set.contains("2024-06-30T04:00:00Z"))
+	assert.False(t, set.contains("2024-12-31"))
+	assert.True(t, set.contains("2025-01-01T08:00:00Z"))
+	assert.False(t, set.contains(20240301))
+	assert.False(t, set.contains((*time.Time)(nil)))
+}
+
+func TestRules_FitBetween(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "between", "key": "Price", "val": "(0, 99.99] ∪ [1e3, +inf)", "id": 1, "msg": "price"},
+	{"op": "between", "key": "Amount", "val": "[0.1, 0.3]", "id": 2, "msg": "amount"},
+	{"op": "between", "key": "Birthday", "val": "[2000-01-01, 2010-01-01)", "id": 3, "msg": "birthday"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	assert.Nil(t, err)
+	type Order struct {
+		Price    float64
+		Amount   string
+		Birthday time.Time
+	}
+	fit, msg := rs.Fit(&Order{Price: 99.99, Amount: "0.30", Birthday: time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC)})
+	assert.True(t, fit, msg)
+	fit, msg = rs.Fit(&Order{Price: 100, Amount: "0.30", Birthday: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "price", 3: "birthday"}, msg)
+
+	fit, _, err = rs.FitJSON([]byte(`{"Price": 1000, "Amount": 0.1, "Birthday": "2000-01-01"}`))
+	assert.Nil(t, err)
+	assert.True(t, fit)
+
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "between", "key": "A", "val": "[3, 1]", "id": 5}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "rule 5: interval")
+	}
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "between", "key": "A", "val": 3, "id": 6}]`), "")
+	assert.NotNil(t, err)
+}
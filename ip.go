@@ -0,0 +1,152 @@
+package ruler
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ipSet IP和CIDR网段构成的前缀树，IPv4和IPv6分别建树，IPv4映射的IPv6地址按IPv4处理
+type ipSet struct {
+	v4, v6 *ipNode
+}
+
+// ipNode 前缀树节点，按地址的二进制位逐级分叉
+type ipNode struct {
+	children [2]*ipNode
+	// 从根到此节点的前缀是集合中的一个网段
+	terminal bool
+}
+
+// newIPSet 逗号分隔的字符串或列表转换为IP集合，元素为IP地址或CIDR网段
+func newIPSet(val interface{}) (*ipSet, error) {
+	var entries []interface{}
+	if str, ok := val.(string); ok {
+		for _, o := range strings.Split(str, ",") {
+			entries = append(entries, o)
+		}
+	} else if elements, ok := listElements(val); ok {
+		entries = elements
+	} else {
+		return nil, fmt.Errorf("value should be a list of IP or CIDR, got %T", val)
+	}
+	set := &ipSet{v4: &ipNode{}, v6: &ipNode{}}
+	for _, o := range entries {
+		str, ok := o.(string)
+		if !ok {
+			return nil, fmt.Errorf("list element %v of %T is not a string", o, o)
+		}
+		prefix, err := parseIPPrefix(strings.TrimSpace(str))
+		if err != nil {
+			return nil, err
+		}
+		set.add(prefix)
+	}
+	return set, nil
+}
+
+// parseIPPrefix 解析IP地址或CIDR网段，单个地址视为全长网段
+func parseIPPrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", s)
+		}
+		prefix = prefix.Masked()
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix, nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP %q", s)
+	}
+	addr = addr.Unmap().WithZone(EmptyStr)
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (set *ipSet) root(addr netip.Addr) *ipNode {
+	if addr.Is4() {
+		return set.v4
+	}
+	return set.v6
+}
+
+// add 插入网段，已被更短网段覆盖的不再插入
+func (set *ipSet) add(prefix netip.Prefix) {
+	node := set.root(prefix.Addr())
+	bytes := prefix.Addr().AsSlice()
+	for i := 0; i < prefix.Bits(); i++ {
+		if node.terminal {
+			return
+		}
+		bit := bytes[i/8] >> (7 - i%8) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &ipNode{}
+		}
+		node = node.children[bit]
+	}
+	node.terminal = true
+	// 更长的网段已被覆盖
+	node.children = [2]*ipNode{}
+}
+
+// contains 地址是否落在任一网段中
+func (set *ipSet) contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	node := set.root(addr)
+	bytes := addr.AsSlice()
+	for i := 0; node != nil; i++ {
+		if node.terminal {
+			return true
+		}
+		if i >= len(bytes)*8 {
+			return false
+		}
+		node = node.children[bytes[i/8]>>(7-i%8)&1]
+	}
+	return false
+}
+
+// toAddr 实际值转为IP地址：netip.Addr、net.IP或IP字符串
+func toAddr(v interface{}) (netip.Addr, bool) {
+	switch t := v.(type) {
+	case netip.Addr:
+		return t, t.IsValid()
+	case *netip.Addr:
+		if t == nil {
+			return netip.Addr{}, false
+		}
+		return *t, t.IsValid()
+	case net.IP:
+		return netip.AddrFromSlice(t)
+	case *net.IP:
+		if t == nil {
+			return netip.Addr{}, false
+		}
+		return netip.AddrFromSlice(*t)
+	case string:
+		addr, err := netip.ParseAddr(strings.TrimSpace(t))
+		return addr, err == nil
+	default:
+		return netip.Addr{}, false
+	}
+}
+
+// fitIP IP相关算符的匹配，实际值不是合法IP时ipin和ipnin都不满足
+func (r *Rule) fitIP(v interface{}) (result bool, handled bool) {
+	if r.Op != "ipin" && r.Op != "ipnin" {
+		return false, false
+	}
+	set, ok := r.compiledVal().(*ipSet)
+	if !ok {
+		return false, true
+	}
+	addr, ok := toAddr(v)
+	if !ok {
+		return false, true
+	}
+	return set.contains(addr) == (r.Op == "ipin"), true
+}
@@ -0,0 +1,87 @@
+package ruler
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPSet(t *testing.T) {
+	set, err := newIPSet("10.0.0.0/8, 192.168.0.0/16, 172.16.5.4, 2001:db8::/32, ::ffff:100.64.0.0/106, 10.1.0.0/16")
+	assert.Nil(t, err)
+	cases := []struct {
+		v      interface{}
+		expect bool
+	}{
+		{"10.255.1.1", true},
+		{"192.168.3.4", true},
+		{"192.169.0.1", false},
+		{"172.16.5.4", true},
+		{"172.16.5.5", false},
+		{"2001:db8:1::1", true},
+		{"2001:db9::1", false},
+		{"100.64.1.1", true},
+		{"::ffff:10.0.0.1", true},
+		{" 10.0.0.1 ", true},
+		{net.ParseIP("192.168.1.1"), true},
+		{net.IPv4(8, 8, 8, 8), false},
+		{netip.MustParseAddr("10.2.3.4"), true},
+		{"not an ip", false},
+		{3, false},
+	}
+	for _, c := range cases {
+		addr, ok := toAddr(c.v)
+		assert.Equal(t, c.expect, ok && set.contains(addr), "%v", c.v)
+	}
+
+	set, err = newIPSet([]interface{}{"0.0.0.0/0"})
+	assert.Nil(t, err)
+	assert.True(t, set.contains(netip.MustParseAddr("1.2.3.4")))
+	assert.False(t, set.contains(netip.MustParseAddr("::1")))
+
+	for _, val := range []interface{}{"10.0.0.0/33", "10.0.0.256", "", []interface{}{1}, 3} {
+		_, err = newIPSet(val)
+		assert.NotNil(t, err, "%v", val)
+	}
+}
+
+func TestRules_FitIP(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "ipin", "key": "ClientIP", "val": "10.0.0.0/8, 192.168.0.0/16", "id": 1, "msg": "not intranet"},
+	{"op": "ipnin", "key": "Addr", "val": ["1.2.3.0/24", "2001:db8::/32"], "id": 2, "msg": "blocked"},
+	{"op": "ipin", "key": "Remote", "val": "::1, 127.0.0.1", "id": 3, "msg": "not loopback"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	assert.Nil(t, err)
+	type Request struct {
+		ClientIP string
+		Addr     netip.Addr
+		Remote   net.IP
+	}
+	fit, msg := rs.Fit(&Request{ClientIP: "10.1.2.3", Addr: netip.MustParseAddr("8.8.8.8"), Remote: net.ParseIP("127.0.0.1")})
+	assert.True(t, fit, msg)
+	fit, msg = rs.Fit(&Request{ClientIP: "8.8.8.8", Addr: netip.MustParseAddr("1.2.3.4"), Remote: net.IPv6loopback})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "not intranet", 2: "blocked"}, msg)
+
+	fit, msg = rs.FitWithMap(map[string]interface{}{"ClientIP": "192.168.0.1", "Addr": "bad", "Remote": "::1"})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "blocked"}, msg)
+
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "ipin", "key": "A", "val": "10.0.0.0/40", "id": 4}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `rule 4: invalid CIDR "10.0.0.0/40"`)
+	}
+
+	type typedRequest struct {
+		ClientIP string
+		Addr     netip.Addr
+		Count    int
+	}
+	_, err = Compile[typedRequest]([]byte(`[{"op": "ipin", "key": "Addr", "val": "10.0.0.0/8", "id": 1}]`), "")
+	assert.Nil(t, err)
+	_, err = Compile[typedRequest]([]byte(`[{"op": "ipin", "key": "Count", "val": "10.0.0.0/8", "id": 1}]`), "")
+	assert.NotNil(t, err)
+}
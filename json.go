@@ -0,0 +1,226 @@
+package ruler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+)
+
+// FitJSON Rules匹配json串，只解码子规则引用到的key，数字以json.Number精确比较
+func (rs *Rules) FitJSON(data []byte) (bool, map[int]string, error) {
+	fit, tips, _, err := rs.FitJSONAskVal(data)
+	return fit, tips, err
+}
+
+// FitJSONAskVal Rules匹配json串，同时返回所有子规则key值
+func (rs *Rules) FitJSONAskVal(data []byte) (bool, map[int]string, map[int]interface{}, error) {
+	o, err := rs.extractJSON(data)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	fit, tips, values := rs.fitWithMapInFact(o)
+	return fit, tips, values, nil
+}
+
+// FitJSON RulesList匹配json串，返回第一个命中的Rules
+func (rst *RulesList) FitJSON(data []byte) (*Rules, error) {
+	for _, rs := range rst.RulesList {
+		flag, _, err := rs.FitJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		if flag {
+			return rs, nil
+		}
+	}
+	return nil, nil
+}
+
+// jsonPathNode 子规则key组成的前缀树，whole表示该节点的值须完整解码
+type jsonPathNode struct {
+	children map[string]*jsonPathNode
+	whole    bool
+}
+
+func (rs *Rules) jsonPathTree() *jsonPathNode {
+	root := &jsonPathNode{children: make(map[string]*jsonPathNode)}
+	for _, rule := range rs.Rules {
+		for _, key := range rule.keyPaths() {
+			paths, err := parseKeyPath(key)
+			if err != nil {
+				continue
+			}
+			node := root
+			for _, step := range paths {
+				child, ok := node.children[step]
+				if !ok {
+					child = &jsonPathNode{children: make(map[string]*jsonPathNode)}
+					node.children[step] = child
+				}
+				node = child
+			}
+			node.whole = true
+		}
+	}
+	return root
+}
+
+// extractJSON 流式读取json，得到只包含所需key的map，对象之后不能再有其他内容
+func (rs *Rules) extractJSON(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	var o map[string]interface{}
+	if tok != nil {
+		if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+			return nil, errors.New("json to fit should be an object")
+		}
+		if o, err = rs.jsonPathTree().extractObject(dec); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after json object")
+	}
+	return o, nil
+}
+
+// extract 读取一个值，不是对象又不需完整解码时跳过，返回是否取到
+func (node *jsonPathNode) extract(dec *json.Decoder) (interface{}, bool, error) {
+	if node.whole {
+		var v interface{}
+		err := dec.Decode(&v)
+		return v, err == nil, err
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, false, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		// 更深的路径不可能在非对象中
+		return nil, false, skipJSONValue(dec, tok)
+	}
+	o, err := node.extractObject(dec)
+	return o, err == nil, err
+}
+
+// extractObject 读取对象的剩余部分，'{'已被读取
+func (node *jsonPathNode) extractObject(dec *json.Decoder) (map[string]interface{}, error) {
+	o := make(map[string]interface{})
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errors.New("invalid json object key")
+		}
+		child, ok := node.children[key]
+		if !ok {
+			if tok, err = dec.Token(); err != nil {
+				return nil, err
+			}
+			if err = skipJSONValue(dec, tok); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		v, got, err := child.extract(dec)
+		if err != nil {
+			return nil, err
+		}
+		if got {
+			o[key] = v
+		}
+	}
+	// 读掉'}'
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// skipJSONValue 跳过以tok开头的值
+func skipJSONValue(dec *json.Decoder, tok json.Token) error {
+	delim, ok := tok.(json.Delim)
+	if !ok || delim == '}' || delim == ']' {
+		return nil
+	}
+	depth := 1
+	for depth > 0 {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := t.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+// isDecimal 是否是需要精确比较的json数字
+func isDecimal(v interface{}) bool {
+	_, ok := v.(json.Number)
+	return ok
+}
+
+// toRat 数字转为精确的有理数，浮点数取其最短十进制表示
+func toRat(v interface{}) (*big.Rat, bool) {
+	var s string
+	switch t := v.(type) {
+	case json.Number:
+		s = t.String()
+	case float64:
+		s = strconv.FormatFloat(t, 'g', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(t), 'g', -1, 32)
+	case uint:
+		return new(big.Rat).SetUint64(uint64(t)), true
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(t)), true
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(t)), true
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(t)), true
+	case uint64:
+		return new(big.Rat).SetUint64(t), true
+	case int:
+		return new(big.Rat).SetInt64(int64(t)), true
+	case int8:
+		return new(big.Rat).SetInt64(int64(t)), true
+	case int16:
+		return new(big.Rat).SetInt64(int64(t)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(t)), true
+	case int64:
+		return new(big.Rat).SetInt64(t), true
+	default:
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// compareDecimal 精确比较两个数字，返回-1、0、1
+func compareDecimal(a, b interface{}) (int, bool) {
+	ra, ok := toRat(a)
+	if !ok {
+		return 0, false
+	}
+	rb, ok := toRat(b)
+	if !ok {
+		return 0, false
+	}
+	return ra.Cmp(rb), true
+}
@@ -0,0 +1,79 @@
+package ruler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_FitJSON(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match"},
+	{"op": "=", "key": "Sex", "val": "male", "id": 2, "msg": "not male"},
+	{"op": ">=", "key": "Score.Math", "val": 90, "id": 3, "msg": "Math not so well"},
+	{"op": ">=", "key": "Score.Physic", "val": 90, "id": 4, "msg": "Physic not so well"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "1 and not 2 and (3 or 4)")
+	if err != nil {
+		t.Error(err)
+	}
+	event := []byte(`{
+	"Name": "Chris", "Grade": 3, "Sex": "female",
+	"History": [{"Grade": 1}, {"Grade": 2, "Score": {"Math": 1}}],
+	"Profile": {"Tags": ["a", "b"], "Deep": {"Deeper": {}}},
+	"Score": {"Math": 88, "Physic": 91, "Chemistry": [1, 2, 3]}
+	}`)
+	fit, msg, values, err := rs.FitJSONAskVal(event)
+	assert.Nil(t, err)
+	assert.True(t, fit)
+	assert.Equal(t, map[int]string{1: "Grade not match", 2: "not male", 4: "Physic not so well"}, msg)
+	assert.Equal(t, json.Number("88"), values[3])
+
+	_, _, err = rs.FitJSON([]byte(`{"Grade": 3,`))
+	assert.NotNil(t, err)
+	_, _, err = rs.FitJSON([]byte(`[1, 2]`))
+	assert.NotNil(t, err)
+}
+
+func TestRules_FitJSONPrecise(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": ">", "key": "ID", "val": 9007199254740992, "id": 1},
+	{"op": "=", "key": "Amount", "val": 0.1, "id": 2}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	if err != nil {
+		t.Error(err)
+	}
+	fit, _, err := rs.FitJSON([]byte(`{"ID": 9007199254740993, "Amount": 0.10}`))
+	assert.Nil(t, err)
+	assert.True(t, fit)
+
+	// float64 can not tell the difference
+	var o map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"ID": 9007199254740993, "Amount": 0.10}`), &o)
+	fit, _ = rs.FitWithMap(o)
+	assert.False(t, fit)
+}
+
+func TestExtractJSON(t *testing.T) {
+	rs := newRulesWithArray([]*Rule{{Key: "a.b"}, {Key: "c"}, {Key: "d.e"}})
+	o, err := rs.extractJSON([]byte(`{"a": {"b": [1, {"x": 2}], "z": 1}, "c": null, "d": 5, "f": {"g": 1}}`))
+	assert.Nil(t, err)
+	expect := map[string]interface{}{
+		"a": map[string]interface{}{"b": []interface{}{json.Number("1"), map[string]interface{}{"x": json.Number("2")}}},
+		"c": nil,
+	}
+	assert.Equal(t, expect, o)
+}
+
+func TestExtractJSON_TrailingData(t *testing.T) {
+	rs := newRulesWithArray([]*Rule{{Op: "=", Key: "A", Val: 1, ID: 1}})
+	for _, data := range []string{`{"A":1} garbage`, `{"A":1} {"A":2}`, `{"A":1}]`, `null 1`} {
+		_, _, err := rs.FitJSON([]byte(data))
+		assert.NotNil(t, err, data)
+	}
+	fit, _, err := rs.FitJSON([]byte(" {\"A\":1}\n "))
+	assert.Nil(t, err)
+	assert.True(t, fit)
+}
@@ -0,0 +1,243 @@
+package ruler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// valueSet 子规则值中的列表转换成的集合，元素保持类型：字符串、数字、布尔互不相等
+type valueSet struct {
+	items  map[interface{}]struct{}
+	keys   []interface{} // 去重后的元素，按原顺序
+	legacy bool          // 由逗号分隔的字符串转换而来，数字元素同时按字符串和数字收录
+}
+
+// newValueSet 列表（json数组或Go切片、数组）或逗号分隔的字符串转换为集合
+func newValueSet(val interface{}) (*valueSet, error) {
+	set := &valueSet{items: make(map[interface{}]struct{})}
+	if str, ok := val.(string); ok {
+		// 兼容"1, 2, 3"：元素同时按字符串和数字收录
+		set.legacy = true
+		for _, o := range strings.Split(str, ",") {
+			o = strings.TrimSpace(o)
+			set.add(o)
+			if _, err := strconv.ParseFloat(o, 64); err == nil {
+				if key, ok := setKey(json.Number(o)); ok {
+					set.items[key] = struct{}{}
+				}
+			}
+		}
+		return set, nil
+	}
+	elements, ok := listElements(val)
+	if !ok {
+		return nil, fmt.Errorf("value should be a list, got %T", val)
+	}
+	for _, o := range elements {
+		key, ok := setKey(o)
+		if !ok {
+			return nil, fmt.Errorf("list element %v of %T is not a scalar", o, o)
+		}
+		set.add(key)
+	}
+	return set, nil
+}
+
+func (set *valueSet) add(key interface{}) {
+	if _, ok := set.items[key]; ok {
+		return
+	}
+	set.items[key] = struct{}{}
+	set.keys = append(set.keys, key)
+}
+
+// has 集合中是否有v
+func (set *valueSet) has(v interface{}) bool {
+	key, ok := setKey(v)
+	if !ok {
+		return false
+	}
+	_, ok = set.items[key]
+	return ok
+}
+
+// setKey 标量统一为集合的键：整数精确转为int64、uint64或bigIntKey，其他数字转float64，字符串和布尔保持原样
+func setKey(v interface{}) (interface{}, bool) {
+	switch t := v.(type) {
+	case string, bool:
+		return t, true
+	case json.Number:
+		if r, ok := new(big.Rat).SetString(t.String()); ok {
+			return ratKey(r), true
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return nil, false
+		}
+		return floatKey(f), true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true
+	case reflect.Bool:
+		return rv.Bool(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u > math.MaxInt64 {
+			return u, true
+		}
+		return int64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return floatKey(rv.Float()), true
+	default:
+		return nil, false
+	}
+}
+
+// bigIntKey 超出int64、uint64范围的整数的键，与字符串区分
+type bigIntKey string
+
+// floatKey 浮点数的键，整数值与整数的键一致
+func floatKey(f float64) interface{} {
+	if math.IsInf(f, 0) || math.IsNaN(f) || f != math.Trunc(f) {
+		return f
+	}
+	return ratKey(new(big.Rat).SetFloat64(f))
+}
+
+// ratKey 精确数值的键，整数按值精确区分，非整数转float64
+func ratKey(r *big.Rat) interface{} {
+	if !r.IsInt() {
+		f, _ := r.Float64()
+		return f
+	}
+	n := r.Num()
+	switch {
+	case n.IsInt64():
+		return n.Int64()
+	case n.IsUint64():
+		return n.Uint64()
+	default:
+		return bigIntKey(n.String())
+	}
+}
+
+// isList 是否是json数组或Go切片、数组（[]byte除外）
+func isList(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		return rv.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+// listElements 列表的元素
+func listElements(v interface{}) ([]interface{}, bool) {
+	if list, ok := v.([]interface{}); ok {
+		return list, true
+	}
+	if !isList(v) {
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	elements := make([]interface{}, rv.Len())
+	for i := range elements {
+		elements[i] = rv.Index(i).Interface()
+	}
+	return elements, true
+}
+
+// inputElements 实际值作为集合运算的输入：列表取元素，字符串按逗号分隔
+func inputElements(v interface{}) ([]interface{}, bool) {
+	if str, ok := v.(string); ok {
+		var elements []interface{}
+		for _, o := range strings.Split(str, ",") {
+			elements = append(elements, strings.TrimSpace(o))
+		}
+		return elements, true
+	}
+	return listElements(v)
+}
+
+// fitList 列表相关算符的匹配，handled为false表示应沿用逗号分隔字符串的原有逻辑
+func (r *Rule) fitList(v interface{}) (result bool, handled bool) {
+	switch r.Op {
+	case "@", "in", "!@", "nin":
+		set, ok := r.compiledVal().(*valueSet)
+		if !ok {
+			return false, false
+		}
+		in := set.has(v)
+		if r.Op == "@" || r.Op == "in" {
+			return in, true
+		}
+		return !in, true
+	case "@@", "intersect":
+		if !isList(v) && !isList(r.Val) {
+			return false, false
+		}
+		set, ok := r.compiledVal().(*valueSet)
+		if !ok {
+			return false, true
+		}
+		elements, ok := inputElements(v)
+		if !ok {
+			return set.has(v), true
+		}
+		for _, o := range elements {
+			if set.has(o) {
+				return true, true
+			}
+		}
+		return false, true
+	case "subset":
+		set, ok := r.compiledVal().(*valueSet)
+		elements, isInput := inputElements(v)
+		if !ok || !isInput {
+			return false, true
+		}
+		for _, o := range elements {
+			if !set.has(o) {
+				return false, true
+			}
+		}
+		return true, true
+	case "superset":
+		set, ok := r.compiledVal().(*valueSet)
+		elements, isInput := inputElements(v)
+		if !ok || !isInput {
+			return false, true
+		}
+		input := &valueSet{items: make(map[interface{}]struct{})}
+		for _, o := range elements {
+			if key, ok := setKey(o); ok {
+				input.add(key)
+			}
+		}
+		for _, key := range set.keys {
+			if _, ok := input.items[key]; ok {
+				continue
+			}
+			if str, ok := key.(string); ok && set.legacy {
+				if _, err := strconv.ParseFloat(str, 64); err == nil && input.has(json.Number(str)) {
+					continue
+				}
+			}
+			return false, true
+		}
+		return true, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,127 @@
+package ruler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewValueSet(t *testing.T) {
+	set, err := newValueSet([]interface{}{"Beijing, China", float64(3), true, "3"})
+	assert.Nil(t, err)
+	assert.True(t, set.has("Beijing, China"))
+	assert.True(t, set.has(3))
+	assert.True(t, set.has(int8(3)))
+	assert.True(t, set.has("3"))
+	assert.True(t, set.has(true))
+	assert.False(t, set.has("Beijing"))
+	assert.False(t, set.has(false))
+
+	set, err = newValueSet([]int{1, 2})
+	assert.Nil(t, err)
+	assert.False(t, set.has("1"))
+	assert.True(t, set.has(2.0))
+
+	_, err = newValueSet([]interface{}{map[string]interface{}{}})
+	assert.NotNil(t, err)
+	_, err = newValueSet(3)
+	assert.NotNil(t, err)
+}
+
+func TestRules_FitListValue(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "in", "key": "City", "val": ["Beijing, China", "Shanghai, China"], "id": 1, "msg": "city"},
+	{"op": "nin", "key": "Level", "val": [1, 2, "3"], "id": 2, "msg": "level"},
+	{"op": "intersect", "key": "Tags", "val": ["vip", "new"], "id": 3, "msg": "tags"},
+	{"op": "subset", "key": "Roles", "val": ["admin", "editor", "viewer"], "id": 4, "msg": "roles"},
+	{"op": "superset", "key": "Scores", "val": [60, 90], "id": 5, "msg": "scores"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	if err != nil {
+		t.Error(err)
+	}
+	type User struct {
+		City   string
+		Level  int
+		Tags   []string
+		Roles  [2]string
+		Scores []int
+	}
+	u := &User{
+		City:   "Beijing, China",
+		Level:  3,
+		Tags:   []string{"old", "vip"},
+		Roles:  [2]string{"admin", "viewer"},
+		Scores: []int{90, 60, 100},
+	}
+	fit, msg := rs.Fit(u)
+	assert.True(t, fit)
+	t.Log(msg)
+
+	u.Level = 2
+	u.Tags = nil
+	u.Roles = [2]string{"admin", "root"}
+	u.Scores = []int{60}
+	fit, msg = rs.Fit(u)
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "level", 3: "tags", 4: "roles", 5: "scores"}, msg)
+
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "in", "key": "A", "val": [[1]], "id": 1}]`), "")
+	assert.NotNil(t, err)
+}
+
+func TestNewValueSet_WideIntegers(t *testing.T) {
+	// 2^53+1与2^53转为float64后相等，作为集合的键须精确区分
+	set, err := newValueSet([]interface{}{json.Number("9007199254740993"), json.Number("18446744073709551617"), 1.5})
+	assert.Nil(t, err)
+	assert.True(t, set.has(int64(9007199254740993)))
+	assert.True(t, set.has(json.Number("9007199254740993")))
+	assert.False(t, set.has(int64(9007199254740992)))
+	assert.False(t, set.has(float64(9007199254740992)))
+	assert.True(t, set.has(json.Number("18446744073709551617")))
+	assert.False(t, set.has(json.Number("18446744073709551616")))
+	assert.False(t, set.has("18446744073709551617"))
+	assert.True(t, set.has(json.Number("1.50")))
+	assert.True(t, set.has(float32(1.5)))
+
+	// 整数值的浮点数与整数相等
+	set, err = newValueSet([]interface{}{float64(3), uint64(1 << 63)})
+	assert.Nil(t, err)
+	assert.True(t, set.has(3))
+	assert.True(t, set.has(json.Number("3.0")))
+	assert.True(t, set.has(float64(1<<63)))
+
+	rs, err := LoadRules([]byte(`{"version": 1, "kind": "rules", "rules": [{"op": "nin", "key": "ID", "val": [9007199254740993], "id": 1}]}`))
+	assert.Nil(t, err)
+	fit, _ := rs.FitWithMap(map[string]interface{}{"ID": int64(9007199254740992)})
+	assert.True(t, fit)
+	fit, _ = rs.FitWithMap(map[string]interface{}{"ID": int64(9007199254740993)})
+	assert.False(t, fit)
+}
+
+func TestRule_FitListLegacy(t *testing.T) {
+	rule := &Rule{Op: "intersect", Val: "bn,gh,kl"}
+	assert.True(t, rule.fit([]string{"as", "gh"}))
+	rule = &Rule{Op: "superset", Val: "1, 2"}
+	assert.True(t, rule.fit([]int{2, 1, 3}))
+	rule = &Rule{Op: "in", Val: "11, 2, 3, 1,  88.1"}
+	assert.True(t, rule.fit(float32(88.1)))
+}
+
+func TestRule_CompiledValLazy(t *testing.T) {
+	// 不经构造方法的子规则首次使用时预处理，之后复用
+	rule := &Rule{Op: "in", Key: "Grade", Val: []interface{}{1, 2, 3}, ID: 1}
+	assert.Nil(t, rule.compiled)
+	set := rule.compiledVal()
+	assert.NotNil(t, set)
+	assert.Same(t, set, rule.compiledVal())
+	rs := &Rules{Rules: []*Rule{rule}}
+	fit, _ := rs.FitWithMap(map[string]interface{}{"Grade": 2})
+	assert.True(t, fit)
+
+	// 值格式错误时同样只处理一次
+	bad := &Rule{Op: "between", Key: "Grade", Val: "[3, 1]", ID: 1}
+	assert.Nil(t, bad.compiledVal())
+	assert.NotNil(t, bad.lazy.Load())
+}
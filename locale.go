@@ -0,0 +1,167 @@
+package ruler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/**
+  按语言的提示：
+  1. Rule.Msgs、Rules.Msgs按语言给出提示，如 {"zh-CN": "年级不符", "en": "Grade not match"}
+  2. 也可以把msg作为消息键，由Rules.Translator翻译
+  3. 语言按回退链查找：zh-Hant-TW依次尝试zh-Hant-TW、zh-Hant、zh，再依次尝试FallbackLocales，都没有时使用msg
+  4. 每种语言先查Msgs再查Translator；子规则不满足且都没有提示时再按同样的顺序查Rules的提示
+  5. 正提示PassMsg、PassMsgs同样按回退链查找，子规则都没有时沿用负提示
+  6. 子规则的msgs、passMsgs中只差大小写或_、-的语言在构造时报错；Rules.Msgs、MapTranslator中等价的语言按字典序取第一个
+*/
+
+// Translator 把消息键翻译为指定语言，没有翻译时ok为false
+type Translator interface {
+	Translate(locale, key string) (msg string, ok bool)
+}
+
+// TranslatorFunc 函数形式的Translator
+type TranslatorFunc func(locale, key string) (string, bool)
+
+// Translate 实现Translator
+func (f TranslatorFunc) Translate(locale, key string) (string, bool) {
+	return f(locale, key)
+}
+
+// MapTranslator 按语言、消息键索引的翻译表
+type MapTranslator map[string]map[string]string
+
+// Translate 实现Translator，语言不区分大小写
+func (m MapTranslator) Translate(locale, key string) (string, bool) {
+	msgs, ok := lookupLocale(m, locale)
+	if !ok {
+		return EmptyStr, false
+	}
+	msg, ok := msgs[key]
+	return msg, ok
+}
+
+// FitLocale Rules匹配结构体，提示使用指定语言；FitWarnLocale、FitResultLocale、ValidateLocale同理
+func (rs *Rules) FitLocale(o interface{}, locale string) (bool, map[int]string) {
+	fit, tips, _ := rs.fitInFact(rs.structGetter(rs.Adapter, o), locale)
+	return fit, tips
+}
+
+// FitWithMapLocale Rules匹配map，提示使用指定语言
+func (rs *Rules) FitWithMapLocale(o map[string]interface{}, locale string) (bool, map[int]string) {
+	fit, tips, _ := rs.fitInFact(mapGetter(o), locale)
+	return fit, tips
+}
+
+// LocalizeTips 把AskVal系列方法返回的提示换成指定语言，values为同时返回的实际值
+// 按子规则自身对实际值的结果选择正负提示，实际值为nil时按key不存在计算；values中没有的子规则使用负提示
+func (rs *Rules) LocalizeTips(tips map[int]string, values map[int]interface{}, locale string) map[int]string {
+	localized := make(map[int]string, len(tips))
+	for _, rule := range rs.Rules {
+		if _, ok := tips[rule.ID]; ok {
+			v, exists := values[rule.ID]
+			localized[rule.ID] = rs.tipIn(rule, v, locale, exists && rule.matched(v))
+		}
+	}
+	return localized
+}
+
+// matched 子规则对AskVal返回的实际值是否满足
+func (r *Rule) matched(v interface{}) bool {
+	if match, ok := v.(*FuzzyMatch); ok {
+		return match.Matched
+	}
+	return r.fitPresent(v, v != nil)
+}
+
+// tipIn 子规则在指定语言下渲染后的提示，pass为true时使用正提示
+func (rs *Rules) tipIn(rule *Rule, v interface{}, locale string, pass bool) string {
+	chain := localeChain(locale, rs.FallbackLocales)
+	if pass && rule.hasPassMsg() {
+		msg, _ := rs.translate(chain, rule.PassMsgs, rule.PassMsg)
+		return renderMsg(msg, rule, v)
+	}
+	msg, ok := rs.translate(chain, rule.Msgs, rule.tip(pass))
+	if !ok && !pass {
+		msg, _ = rs.translate(chain, rs.Msgs, rs.Msg)
+	}
+	return renderMsg(msg, rule, v)
+}
+
+// translate 按回退链查找msgs和Translator，都没有时返回原msg
+func (rs *Rules) translate(chain []string, msgs map[string]string, msg string) (string, bool) {
+	for _, locale := range chain {
+		if localized, ok := lookupLocale(msgs, locale); ok {
+			return localized, true
+		}
+		if rs.Translator != nil && msg != EmptyStr {
+			if localized, ok := rs.Translator.Translate(locale, msg); ok {
+				return localized, true
+			}
+		}
+	}
+	return msg, msg != EmptyStr
+}
+
+// localeChain 语言的回退链，逐级去掉最后一段，再接上fallbacks，去重
+func localeChain(locale string, fallbacks []string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	for _, o := range append([]string{locale}, fallbacks...) {
+		o = normalizeLocale(o)
+		for o != EmptyStr {
+			if !seen[o] {
+				seen[o] = true
+				chain = append(chain, o)
+			}
+			i := strings.LastIndexByte(o, '-')
+			if i < 0 {
+				break
+			}
+			o = o[:i]
+		}
+	}
+	return chain
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// lookupLocale 按语言查找，语言不区分大小写，_与-等价；多个键等价时取字典序最小的
+func lookupLocale[T any](m map[string]T, locale string) (T, bool) {
+	if v, ok := m[locale]; ok {
+		return v, true
+	}
+	for _, key := range sortedKeys(m) {
+		if normalizeLocale(key) == locale {
+			return m[key], true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// checkLocales 语言只差大小写或_、-的键视为重复
+func checkLocales(msgs map[string]string) error {
+	seen := make(map[string]string, len(msgs))
+	for _, locale := range sortedKeys(msgs) {
+		normalized := normalizeLocale(locale)
+		if other, ok := seen[normalized]; ok {
+			return fmt.Errorf("duplicate locale %q and %q", other, locale)
+		}
+		seen[normalized] = locale
+	}
+	return nil
+}
+
+// sortedKeys map的键，按字典序
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
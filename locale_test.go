@@ -0,0 +1,137 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_FitLocale(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match", "msgs": {"zh-CN": "年级不是{{.Expected}}", "zh-TW": "年級不是{{.Expected}}"}},
+	{"op": ">=", "key": "Score.Math", "val": 90, "id": 2, "msg": "math.low"},
+	{"op": "format", "key": "Email", "val": "email", "id": 3},
+	{"op": "=", "key": "Sex", "val": "male", "id": 4}
+	]`), "")
+	assert.Nil(t, err)
+	rs.Msgs = map[string]string{"zh": "{{.Key}}不符合要求"}
+	rs.Translator = MapTranslator{
+		"zh":    {"math.low": "数学成绩{{.Actual}}低于{{.Expected}}", "invalid email address": "邮箱格式错误"},
+		"en":    {"math.low": "Math score {{.Actual}} is below {{.Expected}}"},
+		"zh-HK": {"math.low": "數學成績{{.Actual}}低於{{.Expected}}"},
+	}
+	o := map[string]interface{}{"Grade": 2, "Score": map[string]interface{}{"Math": 85}, "Email": "chris", "Sex": "female"}
+
+	fit, tips := rs.FitWithMapLocale(o, "zh_CN")
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "年级不是3", 2: "数学成绩85低于90", 3: "邮箱格式错误", 4: "Sex不符合要求"}, tips)
+
+	_, tips = rs.FitWithMapLocale(o, "ZH-tw")
+	assert.Equal(t, map[int]string{1: "年級不是3", 2: "数学成绩85低于90", 3: "邮箱格式错误", 4: "Sex不符合要求"}, tips)
+
+	_, tips = rs.FitWithMapLocale(o, "zh-HK")
+	assert.Equal(t, "數學成績85低於90", tips[2])
+
+	// 没有对应语言时回退到msg
+	_, tips = rs.FitWithMapLocale(o, "fr")
+	assert.Equal(t, map[int]string{1: "Grade not match", 2: "math.low", 3: "invalid email address", 4: ""}, tips)
+
+	// FallbackLocales在指定语言之后尝试，不指定语言的Fit也使用
+	rs.FallbackLocales = []string{"en"}
+	_, tips = rs.FitWithMapLocale(o, "fr")
+	assert.Equal(t, "Math score 85 is below 90", tips[2])
+	_, tips = rs.FitWithMap(o)
+	assert.Equal(t, "Math score 85 is below 90", tips[2])
+	assert.Equal(t, "Grade not match", tips[1])
+
+	type Student struct {
+		Grade int
+		Email string
+		Sex   string
+	}
+	_, tips = rs.FitLocale(&Student{Grade: 3, Email: "a@b.cn", Sex: "female"}, "zh-CN")
+	assert.Equal(t, map[int]string{2: "数学成绩低于90", 4: "Sex不符合要求"}, tips)
+
+	// AskVal系列方法的提示也可以换成指定语言
+	_, tips, values := rs.FitWithMapAskVal(o)
+	assert.Equal(t, "数学成绩85低于90", rs.LocalizeTips(tips, values, "zh")[2])
+
+	// 警告模式、结构化结果和表单校验同样可以指定语言
+	rs.Rules[1].Severity = SeverityWarning
+	fit, tips, warnings := rs.FitWithMapWarnLocale(o, "zh-CN")
+	assert.False(t, fit)
+	assert.Equal(t, "年级不是3", tips[1])
+	assert.Equal(t, map[int]string{2: "数学成绩85低于90"}, warnings)
+	_, _, warnings = rs.FitWarnLocale(&Student{Grade: 3}, "zh-HK")
+	assert.Equal(t, map[int]string{2: "數學成績低於90"}, warnings)
+
+	result := rs.FitWithMapResultLocale(o, "zh-TW")
+	assert.Equal(t, "年級不是3", result.Outcomes[0].Msg)
+	result = rs.FitResultLocale(&Student{Grade: 3, Email: "a@b.cn", Sex: "male"}, "zh")
+	assert.Equal(t, "数学成绩低于90", result.Outcomes[1].Msg)
+
+	ok, fields := rs.ValidateWithMapLocale(o, "zh-CN")
+	assert.False(t, ok)
+	assert.Equal(t, map[string][]string{"Grade": {"年级不是3"}, "Email": {"邮箱格式错误"}, "Sex": {"Sex不符合要求"}}, fields)
+	_, fields = rs.ValidateLocale(&Student{Grade: 2, Email: "a@b.cn", Sex: "male"}, "zh-TW")
+	assert.Equal(t, map[string][]string{"Grade": {"年級不是3"}}, fields)
+}
+
+func TestRules_LocalizeTipsByOutcome(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match", "passMsg": "Grade is {{.Actual}}", "passMsgs": {"zh": "年级是{{.Actual}}"}, "msgs": {"zh": "年级不符"}},
+	{"op": "nempty", "key": "Name", "id": 2, "msgs": {"zh": "缺少姓名"}}
+	]`), "1 or 2")
+	assert.Nil(t, err)
+	// 按子规则自身的结果选择正负提示
+	_, tips, values := rs.FitWithMapAskVal(map[string]interface{}{"Grade": 3})
+	assert.Equal(t, map[int]string{1: "年级是3"}, rs.LocalizeTips(tips, values, "zh"))
+	assert.Equal(t, map[int]string{1: "年级不符", 2: "缺少姓名"}, rs.LocalizeTips(map[int]string{1: "", 2: ""}, map[int]interface{}{1: 4, 2: nil}, "zh"))
+	assert.Equal(t, map[int]string{1: "年级不符"}, rs.LocalizeTips(map[int]string{1: ""}, nil, "zh"))
+}
+
+func TestLocaleChain(t *testing.T) {
+	assert.Equal(t, []string{"zh-hant-tw", "zh-hant", "zh", "en-us", "en"}, localeChain("zh_Hant_TW", []string{"en-US", "zh"}))
+	assert.Equal(t, []string{"en"}, localeChain("", []string{"en"}))
+	assert.Nil(t, localeChain("", nil))
+}
+
+func TestRules_MsgsDocument(t *testing.T) {
+	rs, err := NewRulesWithDSL(`Grade = 3 @msg("Grade not match") @msgs({"zh-CN": "年级不符"})`)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"zh-CN": "年级不符"}, rs.Rules[0].Msgs)
+	dsl, err := rs.DSL()
+	assert.Nil(t, err)
+	assert.Equal(t, `Grade = 3 @msg("Grade not match") @msgs({"zh-CN":"年级不符"})`, dsl)
+
+	rs.Msgs = map[string]string{"en": "not a good student"}
+	data, err := rs.MarshalJSON()
+	assert.Nil(t, err)
+	loaded, err := LoadRules(data)
+	assert.Nil(t, err)
+	assert.Equal(t, rs.Msgs, loaded.Msgs)
+	assert.Equal(t, rs.Rules[0].Msgs, loaded.Rules[0].Msgs)
+
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": 1, "id": 1, "msgs": {"en": "{{.Actual"}}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "rule 1: invalid msg template of en")
+	}
+}
+
+func TestLookupLocale_Deterministic(t *testing.T) {
+	translator := MapTranslator{"zh-CN": {"k": "简体"}, "zh_cn": {"k": "另一份"}, "ZH-cn": {"k": "第三份"}}
+	for i := 0; i < 20; i++ {
+		msg, ok := translator.Translate("zh-cn", "k")
+		assert.True(t, ok)
+		assert.Equal(t, "第三份", msg)
+	}
+
+	_, err := NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": 1, "id": 1, "msgs": {"zh-CN": "a", "zh_cn": "b"}}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `rule 1: msgs: duplicate locale "zh-CN" and "zh_cn"`, err.Error())
+	}
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": 1, "id": 1, "passMsgs": {"EN": "a", "en": "b"}}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `rule 1: passMsgs: duplicate locale "EN" and "en"`, err.Error())
+	}
+}
@@ -0,0 +1,116 @@
+package ruler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+/**
+  提示模板：Rule.Msg和Rules.Msg可以是text/template模板，如 "Math score {{.Actual}} is below {{.Expected}}"
+  1. 可用字段见MsgData
+  2. 子规则不满足且没有msg时依次使用算符的默认提示、Rules.Msg，Rules.Msg不用于满足的子规则
+  3. 提示按子规则自身的结果选择：满足时使用passMsg，没有时沿用msg
+  4. 子规则自身的模板在构造时解析并试执行，出错时构造报错，解析结果存于子规则；Rules.Msg、Translator给出的模板即时解析，执行出错时原样返回msg
+*/
+
+// MsgData 提示模板可用的字段
+type MsgData struct {
+	ID       int         // 子规则ID
+	Key      string      // 子规则key
+	Op       string      // 算符
+	Actual   interface{} // 取到的实际值，key不存在或为nil时为空字符串
+	Expected interface{} // 子规则的值，为nil时为空字符串
+}
+
+// parseMsg 解析msg模板，不含{{的msg返回nil
+func parseMsg(msg string) (*template.Template, error) {
+	if !strings.Contains(msg, "{{") {
+		return nil, nil
+	}
+	return template.New("msg").Parse(msg)
+}
+
+// parseTemplates 解析并试执行子规则自身的msg、msgs、passMsg、passMsgs模板，按msg索引，没有模板时为nil
+func (r *Rule) parseTemplates() (map[string]*template.Template, error) {
+	var templates map[string]*template.Template
+	// 试执行用key不存在时的字段，引用了MsgData中没有的字段等错误在构造时即报错
+	dry := &MsgData{ID: r.ID, Key: r.Key, Op: r.Op, Actual: EmptyStr, Expected: orEmpty(r.Val)}
+	add := func(msg, name, locale string) error {
+		tmpl, err := parseMsg(msg)
+		if err == nil && tmpl != nil {
+			err = tmpl.Execute(io.Discard, dry)
+		}
+		if err != nil {
+			if locale != EmptyStr {
+				name += " template of " + locale
+			} else {
+				name += " template"
+			}
+			return fmt.Errorf("invalid %s: %s", name, err.Error())
+		}
+		if tmpl != nil {
+			if templates == nil {
+				templates = make(map[string]*template.Template)
+			}
+			templates[msg] = tmpl
+		}
+		return nil
+	}
+	if err := add(r.Msg, "msg", EmptyStr); err != nil {
+		return nil, err
+	}
+	for _, locale := range sortedKeys(r.Msgs) {
+		if err := add(r.Msgs[locale], "msg", locale); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(r.PassMsg, "passMsg", EmptyStr); err != nil {
+		return nil, err
+	}
+	for _, locale := range sortedKeys(r.PassMsgs) {
+		if err := add(r.PassMsgs[locale], "passMsg", locale); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+// renderMsg 用子规则和实际值渲染msg，子规则构造时解析过的模板直接使用，其他的即时解析
+func renderMsg(msg string, rule *Rule, v interface{}) string {
+	tmpl, ok := rule.templates[msg]
+	if !ok {
+		var err error
+		if tmpl, err = parseMsg(msg); err != nil || tmpl == nil {
+			return msg
+		}
+	}
+	if match, ok := v.(*FuzzyMatch); ok {
+		v = match.Value
+	}
+	var buf strings.Builder
+	data := &MsgData{ID: rule.ID, Key: rule.Key, Op: rule.Op, Actual: orEmpty(v), Expected: orEmpty(rule.Val)}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return msg
+	}
+	return buf.String()
+}
+
+// tipOf 子规则渲染后的提示，使用FallbackLocales中的语言
+func (rs *Rules) tipOf(rule *Rule, v interface{}, pass bool) string {
+	return rs.tipIn(rule, v, EmptyStr, pass)
+}
+
+// hasPassMsg 子规则是否有正提示
+func (r *Rule) hasPassMsg() bool {
+	return r.PassMsg != EmptyStr || len(r.PassMsgs) > 0
+}
+
+// orEmpty nil在模板中会渲染为<no value>，换成空字符串
+func orEmpty(v interface{}) interface{} {
+	if v == nil {
+		return EmptyStr
+	}
+	return v
+}
@@ -0,0 +1,132 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_FitMsgTemplate(t *testing.T) {
+	rules := []byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade {{.Actual}} is not {{.Expected}}"},
+	{"op": ">=", "key": "Score.Math", "val": 90, "id": 2, "msg": "Math score {{.Actual}} is below {{.Expected}}"},
+	{"op": "in", "key": "Sex", "val": ["male", "female"], "id": 3},
+	{"op": "levenshtein", "key": "Name", "val": {"target": "Chris", "distance": 1}, "id": 4, "msg": "{{.Key}} {{.Actual}} is not like Chris"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogicAndInfo(rules, "", map[string]string{"msg": "rule {{.ID}}: {{.Key}} {{.Op}} {{.Expected}}, got {{.Actual}}"})
+	assert.Nil(t, err)
+
+	fit, tips := rs.FitWithMap(map[string]interface{}{"Grade": 2, "Score": map[string]interface{}{"Math": 85}, "Sex": "unknown", "Name": "Bob"})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{
+		1: "Grade 2 is not 3",
+		2: "Math score 85 is below 90",
+		3: "rule 3: Sex in [male female], got unknown",
+		4: "Name Bob is not like Chris",
+	}, tips)
+
+	type Student struct {
+		Grade int
+		Score struct{ Math float64 }
+		Sex   string
+		Name  string
+	}
+	o := &Student{Grade: 3, Sex: "male", Name: "Chriss"}
+	o.Score.Math = 72.5
+	fit, tips = rs.Fit(o)
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "Math score 72.5 is below 90"}, tips)
+
+	// fit为true时Rules.Msg不作为子规则的提示
+	o.Score.Math = 95
+	fit, tips = rs.Fit(o)
+	assert.True(t, fit)
+	assert.Equal(t, EmptyStr, tips[3])
+
+	// 没有logic时同样渲染
+	rs, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "<", "key": "Age", "val": 18, "id": 1, "msg": "{{.Key}} should be less than {{.Expected}}, got {{.Actual}}"}]`), "")
+	assert.Nil(t, err)
+	_, tips = rs.FitWithMap(map[string]interface{}{"Age": 20})
+	assert.Equal(t, "Age should be less than 18, got 20", tips[1])
+	_, tips = rs.FitWithMap(map[string]interface{}{})
+	assert.Equal(t, "Age should be less than 18, got ", tips[1])
+}
+
+func TestRules_MsgTemplateErrors(t *testing.T) {
+	_, err := NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": 1, "id": 1, "msg": "A is {{.Actual"}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "rule 1: invalid msg template")
+	}
+
+	// 能解析但执行出错的模板在构造时报错
+	for _, msg := range []string{"S {{.Actual}} below {{.Expected}} {{.Nope}}", "{{.Actual.Foo}}"} {
+		_, err = NewRulesWithArrayAndLogic([]*Rule{{Op: "=", Key: "A", Val: 1, ID: 1, Msg: msg}}, "")
+		if assert.NotNil(t, err, msg) {
+			assert.Contains(t, err.Error(), "rule 1: invalid msg template", msg)
+		}
+	}
+	_, err = NewRulesWithArrayAndLogic([]*Rule{{Op: "=", Key: "A", Val: 1, ID: 1, PassMsgs: map[string]string{"en": "{{.Nope}}"}}}, "")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "rule 1: invalid passMsg template of en")
+	}
+
+	// Rules.Msg即时解析，不合法时原样返回
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": 1, "id": 1, "msg": "A is {{.Actual}}"}, {"op": "=", "key": "B", "val": 1, "id": 2}]`), "")
+	assert.Nil(t, err)
+	rs.Msg = "{{.Nope"
+	_, tips := rs.FitWithMap(map[string]interface{}{"A": 2, "B": 2})
+	assert.Equal(t, map[int]string{1: "A is 2", 2: "{{.Nope"}, tips)
+	rs.Msg = "{{.Nope}}"
+	_, tips = rs.FitWithMap(map[string]interface{}{"A": 1, "B": 2})
+	assert.Equal(t, map[int]string{2: "{{.Nope}}"}, tips)
+}
+
+func TestRule_Templates(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "A", "val": 1, "id": 1, "msg": "A is {{.Actual}}", "msgs": {"zh": "A是{{.Actual}}"}, "passMsg": "A ok"},
+	{"op": "=", "key": "B", "val": 1, "id": 2, "msg": "B not match"}
+	]`), "")
+	assert.Nil(t, err)
+	// 模板在构造时解析并存于子规则，不含{{的msg不解析
+	assert.Len(t, rs.Rules[0].templates, 2)
+	assert.Nil(t, rs.Rules[1].templates)
+
+	// 不经构造方法的子规则即时解析
+	literal := &Rules{Rules: []*Rule{{Op: "=", Key: "A", Val: 1, ID: 1, Msg: "A is {{.Actual}}"}}}
+	_, tips := literal.FitWithMap(map[string]interface{}{"A": 2})
+	assert.Equal(t, "A is 2", tips[1])
+	assert.Nil(t, literal.Rules[0].templates)
+}
+
+func TestRules_TipsRenderedOnDemand(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "A", "val": 1, "id": 1, "msg": "a"},
+	{"op": "=", "key": "B", "val": 1, "id": 2, "msg": "b"},
+	{"op": "=", "key": "C", "val": 1, "id": 3, "msg": "c"}
+	]`), "1 or (2 and 3)")
+	assert.Nil(t, err)
+	var keys []string
+	rs.FallbackLocales = []string{"en"}
+	rs.Translator = TranslatorFunc(func(locale, key string) (string, bool) {
+		keys = append(keys, key)
+		return EmptyStr, false
+	})
+	// 只渲染返回的提示
+	fit, tips := rs.FitWithMap(map[string]interface{}{"A": 1, "B": 0, "C": 0})
+	assert.True(t, fit)
+	assert.Equal(t, map[int]string{1: "a"}, tips)
+	assert.Equal(t, []string{"a"}, keys)
+
+	keys = nil
+	fit, tips = rs.FitWithMap(map[string]interface{}{"A": 0, "B": 0, "C": 0})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "a"}, tips)
+	assert.Equal(t, []string{"a"}, keys)
+
+	rs.Logic = EmptyStr
+	keys = nil
+	fit, tips = rs.FitWithMap(map[string]interface{}{"A": 1, "B": 0, "C": 1})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "b"}, tips)
+	assert.Equal(t, []string{"b"}, keys)
+}
@@ -0,0 +1,151 @@
+package ruler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/**
+  子规则key的路径语法：
+  1. 点分隔：Score.Math，反斜杠转义：Version.v1\.2
+  2. 方括号：Headers["X-Forwarded.For"]、Headers['a b']、Tags[abc]，引号内支持反斜杠转义
+  3. JSON Pointer（RFC 6901）：以/开头，/a/b~1c 即 a -> b/c，~0表示~
+*/
+
+// parseKeyPath 把key解析为逐级的键名
+func parseKeyPath(key string) ([]string, error) {
+	if key == EmptyStr {
+		return nil, fmt.Errorf("empty key")
+	}
+	if strings.HasPrefix(key, "/") {
+		return parseJSONPointer(key)
+	}
+	if !strings.ContainsAny(key, "\\[]") {
+		return strings.Split(key, "."), nil
+	}
+	var paths []string
+	var buf strings.Builder
+	// 刚结束一个方括号段，后面只能是.或[
+	var afterBracket bool
+	runes := []rune(key)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("key %q: dangling escape", key)
+			}
+			i++
+			buf.WriteRune(runes[i])
+		case c == '.':
+			if !afterBracket {
+				paths = append(paths, buf.String())
+			}
+			buf.Reset()
+			afterBracket = false
+			continue
+		case c == '[':
+			if buf.Len() > 0 {
+				paths = append(paths, buf.String())
+				buf.Reset()
+			} else if i > 0 && !afterBracket && runes[i-1] != '.' {
+				return nil, fmt.Errorf("key %q: empty step before '['", key)
+			}
+			step, next, err := parseBracket(runes, i+1)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %s", key, err.Error())
+			}
+			paths = append(paths, step)
+			i = next
+			afterBracket = true
+			continue
+		case c == ']':
+			return nil, fmt.Errorf("key %q: unexpected ']'", key)
+		default:
+			if afterBracket {
+				return nil, fmt.Errorf("key %q: unexpected %q after ']'", key, c)
+			}
+			buf.WriteRune(c)
+		}
+	}
+	if !afterBracket {
+		paths = append(paths, buf.String())
+	}
+	return paths, nil
+}
+
+// parseBracket 解析方括号内的键名，start为'['后的位置，返回']'的位置
+func parseBracket(runes []rune, start int) (string, int, error) {
+	var buf strings.Builder
+	if start < len(runes) && (runes[start] == '"' || runes[start] == '\'') {
+		quote := runes[start]
+		for i := start + 1; i < len(runes); i++ {
+			c := runes[i]
+			if c == '\\' && i+1 < len(runes) {
+				i++
+				buf.WriteRune(runes[i])
+				continue
+			}
+			if c == quote {
+				if i+1 >= len(runes) || runes[i+1] != ']' {
+					return EmptyStr, 0, fmt.Errorf("expect ']' after closing quote")
+				}
+				return buf.String(), i + 1, nil
+			}
+			buf.WriteRune(c)
+		}
+		return EmptyStr, 0, fmt.Errorf("unterminated quote")
+	}
+	for i := start; i < len(runes); i++ {
+		if runes[i] == ']' {
+			return strings.TrimSpace(buf.String()), i, nil
+		}
+		buf.WriteRune(runes[i])
+	}
+	return EmptyStr, 0, fmt.Errorf("unterminated '['")
+}
+
+// parseJSONPointer 解析RFC 6901 JSON Pointer
+func parseJSONPointer(key string) ([]string, error) {
+	steps := strings.Split(key[1:], "/")
+	for index, step := range steps {
+		if strings.Contains(strings.NewReplacer("~0", "", "~1", "").Replace(step), "~") {
+			return nil, fmt.Errorf("key %q: invalid escape in json pointer", key)
+		}
+		steps[index] = strings.NewReplacer("~1", "/", "~0", "~").Replace(step)
+	}
+	return steps, nil
+}
+
+// pluckPath 按键名逐级取值，支持map[string]interface{}、map[string]string及键类型为string的任意map
+func pluckPath(paths []string, o interface{}) interface{} {
+	v, _ := lookupPath(paths, o)
+	return v
+}
+
+// lookupPath 同pluckPath，同时返回key是否存在（值为nil也算存在）
+func lookupPath(paths []string, o interface{}) (interface{}, bool) {
+	for _, step := range paths {
+		var ok bool
+		switch m := o.(type) {
+		case map[string]interface{}:
+			o, ok = m[step]
+		case map[string]string:
+			o, ok = m[step]
+		default:
+			v := reflect.ValueOf(o)
+			if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			item := v.MapIndex(reflect.ValueOf(step).Convert(v.Type().Key()))
+			if ok = item.IsValid(); ok {
+				o = item.Interface()
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return o, true
+}
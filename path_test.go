@@ -0,0 +1,79 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeyPath(t *testing.T) {
+	cases := map[string][]string{
+		"Score.Math":                      {"Score", "Math"},
+		`Features.v1\.2`:                  {"Features", "v1.2"},
+		`Headers["X-Forwarded.For"]`:      {"Headers", "X-Forwarded.For"},
+		`Headers['a b'].Deep`:             {"Headers", "a b", "Deep"},
+		`Headers["say \"hi\""]["x.y"]`:    {"Headers", `say "hi"`, "x.y"},
+		`["域名.com"].价格`:                   {"域名.com", "价格"},
+		`Tags[abc]`:                       {"Tags", "abc"},
+		"/a/b~1c/d~0e":                    {"a", "b/c", "d~e"},
+		"/":                               {""},
+		`a\\b`:                            {`a\b`},
+		"a..b":                            {"a", "", "b"},
+		`Headers.["Content.Type"].Length`: {"Headers", "Content.Type", "Length"},
+	}
+	for key, expect := range cases {
+		paths, err := parseKeyPath(key)
+		assert.Nil(t, err, key)
+		assert.Equal(t, expect, paths, key)
+	}
+
+	for _, key := range []string{"", `a\`, `a["b]`, `a["b"x]`, "a[b", "a]b", "a[b]c", "/a~2"} {
+		_, err := parseKeyPath(key)
+		assert.NotNil(t, err, key)
+	}
+}
+
+func TestPluckPath(t *testing.T) {
+	type Level string
+	obj := map[string]interface{}{
+		"Headers": map[string]string{"X-Forwarded.For": "10.0.0.1"},
+		"Levels":  map[Level]int{"gold": 3},
+		"Deep":    map[string]map[string]interface{}{"a.b": {"c": 1}},
+		"Any":     map[string]any{"x": "y"},
+	}
+	assert.Equal(t, "10.0.0.1", pluck(`Headers["X-Forwarded.For"]`, obj))
+	assert.Nil(t, pluck(`Headers["X-Real-IP"]`, obj))
+	assert.Equal(t, 3, pluck("Levels.gold", obj))
+	assert.Equal(t, 1, pluck("/Deep/a.b/c", obj))
+	assert.Equal(t, "y", pluck("Any.x", obj))
+	assert.Nil(t, pluck("Any.x.y", obj))
+}
+
+func TestRules_FitWithKeyPath(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "=", "key": "Headers[\"X-Forwarded.For\"]", "val": "10.0.0.1", "id": 1},
+	{"op": ">=", "key": "/Features/v1.2", "val": 2, "id": 2},
+	{"op": "=", "key": "Features.v1\\.2", "val": 2, "id": 3}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	if err != nil {
+		t.Error(err)
+	}
+	type Request struct {
+		Headers  map[string]string
+		Features map[string]int
+	}
+	r := &Request{
+		Headers:  map[string]string{"X-Forwarded.For": "10.0.0.1"},
+		Features: map[string]int{"v1.2": 2},
+	}
+	fit, _ := rs.Fit(r)
+	assert.True(t, fit)
+
+	fit, _, err = rs.FitJSON([]byte(`{"Headers": {"X-Forwarded.For": "10.0.0.1"}, "Features": {"v1.2": 2}}`))
+	assert.Nil(t, err)
+	assert.True(t, fit)
+
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "Headers[\"a", "val": 1, "id": 1}]`), "")
+	assert.NotNil(t, err)
+}
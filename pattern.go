@@ -0,0 +1,146 @@
+package ruler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/**
+  通配符算符，构造时转换为正则表达式：
+  1. like/nlike：SQL LIKE，%匹配任意个字符，_匹配单个字符，反斜杠转义，如 "10\%%"
+  2. glob：*匹配任意个字符，?匹配单个字符，[a-z]、[!abc]、[^abc]匹配字符集，反斜杠转义
+  3. ilike/nilike、iglob为忽略大小写的版本
+*/
+
+// isPatternOp 是否是通配符算符
+func isPatternOp(op string) bool {
+	switch op {
+	case "like", "nlike", "ilike", "nilike", "glob", "iglob":
+		return true
+	default:
+		return false
+	}
+}
+
+// compilePattern 通配符模式转换为整体匹配的正则表达式
+func compilePattern(op string, val interface{}) (*regexp.Regexp, error) {
+	pattern, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("value should be a pattern string, got %T", val)
+	}
+	var expr string
+	var err error
+	if op == "glob" || op == "iglob" {
+		expr, err = globToRegexp(pattern)
+	} else {
+		expr, err = likeToRegexp(pattern)
+	}
+	if err != nil {
+		return nil, err
+	}
+	flags := "(?s)"
+	if op == "ilike" || op == "nilike" || op == "iglob" {
+		flags = "(?is)"
+	}
+	return regexp.Compile(flags + "^" + expr + "$")
+}
+
+func likeToRegexp(pattern string) (string, error) {
+	var buf strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '\\':
+			if i+1 >= len(runes) {
+				return EmptyStr, fmt.Errorf("pattern %q: dangling escape", pattern)
+			}
+			i++
+			buf.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case '%':
+			buf.WriteString(".*")
+		case '_':
+			buf.WriteString(".")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return buf.String(), nil
+}
+
+func globToRegexp(pattern string) (string, error) {
+	var buf strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '\\':
+			if i+1 >= len(runes) {
+				return EmptyStr, fmt.Errorf("pattern %q: dangling escape", pattern)
+			}
+			i++
+			buf.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case '*':
+			buf.WriteString(".*")
+		case '?':
+			buf.WriteString(".")
+		case '[':
+			end := i + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+			// 紧跟的]是字符集中的字符
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				return EmptyStr, fmt.Errorf("pattern %q: unterminated '['", pattern)
+			}
+			class := runes[i+1 : end]
+			buf.WriteByte('[')
+			if len(class) > 0 && (class[0] == '!' || class[0] == '^') {
+				buf.WriteByte('^')
+				class = class[1:]
+			}
+			if len(class) == 0 {
+				return EmptyStr, fmt.Errorf("pattern %q: empty character class", pattern)
+			}
+			for _, r := range class {
+				if r == '\\' || r == '[' || r == ']' || r == '^' {
+					buf.WriteByte('\\')
+				}
+				buf.WriteRune(r)
+			}
+			buf.WriteByte(']')
+			i = end
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if _, err := regexp.Compile(buf.String()); err != nil {
+		return EmptyStr, fmt.Errorf("pattern %q: %s", pattern, err.Error())
+	}
+	return buf.String(), nil
+}
+
+// fitPattern 通配符算符的匹配，实际值不是字符串或整数时都不满足
+func (r *Rule) fitPattern(v interface{}) (result bool, handled bool) {
+	if !isPatternOp(r.Op) {
+		return false, false
+	}
+	re, ok := r.compiledVal().(*regexp.Regexp)
+	if !ok {
+		return false, true
+	}
+	s, ok := formatInput(v)
+	if !ok {
+		return false, true
+	}
+	matched := re.MatchString(s)
+	if r.Op == "nlike" || r.Op == "nilike" {
+		return !matched, true
+	}
+	return matched, true
+}
@@ -0,0 +1,80 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompilePattern(t *testing.T) {
+	cases := map[string]map[string]map[string]bool{
+		"like": {
+			"abc%":     {"abcdef": true, "abc": true, "xabc": false, "ABCdef": false},
+			"%.com":    {"a.com": true, "acom": false},
+			"a_c":      {"abc": true, "ac": false, "abbc": false},
+			`10\%%`:    {"10% off": true, "100 off": false},
+			"(a)+[b]$": {"(a)+[b]$": true, "aab": false},
+			"%":        {"": true, "multi\nline": true},
+		},
+		"ilike": {"abc%": {"ABCdef": true, "xabc": false}},
+		"glob": {
+			"*.go":      {"main.go": true, "main.gox": false},
+			"file?.txt": {"file1.txt": true, "file12.txt": false},
+			"[a-c]*":    {"apple": true, "dog": false},
+			"[!a-c]*":   {"dog": true, "apple": false},
+			"[^0-9]":    {"x": true, "5": false},
+			"[]]":       {"]": true},
+			`\*literal`: {"*literal": true, "xliteral": false},
+			"user_%":    {"user_%": true, "user1%": false},
+		},
+		"iglob": {"*.GO": {"main.go": true}},
+	}
+	for op, patterns := range cases {
+		for pattern, values := range patterns {
+			re, err := compilePattern(op, pattern)
+			if !assert.Nil(t, err, "%s %s", op, pattern) {
+				continue
+			}
+			for s, expect := range values {
+				assert.Equal(t, expect, re.MatchString(s), "%s %s %s", op, pattern, s)
+			}
+		}
+	}
+
+	for op, pattern := range map[string]string{"like": `abc\`, "glob": "[a-", "iglob": "[]", "nlike": "a\\"} {
+		_, err := compilePattern(op, pattern)
+		assert.NotNil(t, err, "%s %s", op, pattern)
+	}
+	_, err := compilePattern("like", 1)
+	assert.NotNil(t, err)
+}
+
+func TestRules_FitPattern(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "like", "key": "Name", "val": "Ch%", "id": 1, "msg": "name"},
+	{"op": "nilike", "key": "Email", "val": "%@TEST.com", "id": 2, "msg": "test email"},
+	{"op": "glob", "key": "Path", "val": "/api/v[12]/*", "id": 3, "msg": "path"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	assert.Nil(t, err)
+	type Request struct {
+		Name  string
+		Email string
+		Path  string
+	}
+	fit, msg := rs.Fit(&Request{Name: "Chris", Email: "chris@example.com", Path: "/api/v2/users"})
+	assert.True(t, fit, msg)
+	fit, msg = rs.Fit(&Request{Name: "chris", Email: "chris@test.COM", Path: "/api/v3/users"})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "name", 2: "test email", 3: "path"}, msg)
+
+	fit, _ = rs.FitWithMap(map[string]interface{}{"Name": "Chris", "Path": "/api/v1/x"})
+	assert.False(t, fit)
+
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "glob", "key": "A", "val": "[abc", "id": 4}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "rule 4: pattern")
+	}
+	_, err = Compile[typedStudent]([]byte(`[{"op": "like", "key": "Grade", "val": "1%", "id": 1}]`), "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,374 @@
+package ruler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/**
+  规则的自然语言描述，供看不懂json和逻辑ID的业务人员审阅：
+  Grade equals 3, and Sex is not 'male', and either Math score is at least 90 or Physic score is at least 90
+  年级等于3，且性别不等于“male”，且（数学成绩不小于90或物理成绩不小于90）
+  1. 内置zh-CN和en两种语言，zh、en-US等按语言前缀匹配
+  2. Labels把key换成字段名称，未配置的key原样输出
+  3. not作用于单条子规则时使用否定的说法，作用于组合条件时加上“并非”
+*/
+
+// Renderer 规则的自然语言描述
+type Renderer struct {
+	Locale string            // 语言，如 zh-CN、en，默认en
+	Labels map[string]string // key对应的字段名称，如 "Score.Math": "Math score"
+}
+
+// renderJoin 组合条件的连接方式
+type renderJoin struct {
+	prefix, sep, suffix string
+}
+
+func (j renderJoin) join(items []string) string {
+	return j.prefix + strings.Join(items, j.sep) + j.suffix
+}
+
+// renderLocale 一种语言的说法，模板中{key}、{val}分别替换为字段名称和值
+type renderLocale struct {
+	ops map[string][2]string // 算符的肯定、否定说法
+
+	andTop, andNested renderJoin
+	orTop, orNested   renderJoin
+	not               string
+
+	quote      [2]string
+	listSep    string
+	null       string
+	circle     string // {radius} {lat} {lon}
+	area       string
+	distance   string // {n}
+	similarity string // {n}
+}
+
+var renderLocales = map[string]*renderLocale{
+	"en": {
+		ops: map[string][2]string{
+			"=":           {"{key} equals {val}", "{key} is not {val}"},
+			"!=":          {"{key} is not {val}", "{key} equals {val}"},
+			">":           {"{key} is greater than {val}", "{key} is at most {val}"},
+			"<":           {"{key} is less than {val}", "{key} is at least {val}"},
+			">=":          {"{key} is at least {val}", "{key} is less than {val}"},
+			"<=":          {"{key} is at most {val}", "{key} is greater than {val}"},
+			"in":          {"{key} is one of {val}", "{key} is none of {val}"},
+			"nin":         {"{key} is none of {val}", "{key} is one of {val}"},
+			"regex":       {"{key} matches the regular expression {val}", "{key} does not match the regular expression {val}"},
+			"empty":       {"{key} is empty", "{key} is not empty"},
+			"nempty":      {"{key} is not empty", "{key} is empty"},
+			"between":     {"{key} is within {val}", "{key} is outside {val}"},
+			"intersect":   {"{key} contains any of {val}", "{key} contains none of {val}"},
+			"subset":      {"{key} contains only values from {val}", "{key} contains values other than {val}"},
+			"superset":    {"{key} contains all of {val}", "{key} does not contain all of {val}"},
+			"exists":      {"{key} is present", "{key} is missing"},
+			"notexists":   {"{key} is missing", "{key} is present"},
+			"null":        {"{key} is null", "{key} is not null"},
+			"notnull":     {"{key} is not null", "{key} is null"},
+			"blank":       {"{key} is blank", "{key} is not blank"},
+			"notblank":    {"{key} is not blank", "{key} is blank"},
+			"ipin":        {"{key} is an IP in {val}", "{key} is not an IP in {val}"},
+			"ipnin":       {"{key} is not an IP in {val}", "{key} is an IP in {val}"},
+			"ver=":        {"{key} is version {val}", "{key} is not version {val}"},
+			"ver>":        {"{key} is a version later than {val}", "{key} is version {val} or earlier"},
+			"ver>=":       {"{key} is version {val} or later", "{key} is a version earlier than {val}"},
+			"ver<":        {"{key} is a version earlier than {val}", "{key} is version {val} or later"},
+			"ver<=":       {"{key} is version {val} or earlier", "{key} is a version later than {val}"},
+			"verbetween":  {"{key} is a version within {val}", "{key} is a version outside {val}"},
+			"semver":      {"{key} is a version satisfying {val}", "{key} is a version not satisfying {val}"},
+			"georadius":   {"{key} is within {val}", "{key} is not within {val}"},
+			"geopolygon":  {"{key} is inside {val}", "{key} is outside {val}"},
+			"format":      {"{key} is a valid {val}", "{key} is not a valid {val}"},
+			"like":        {"{key} matches the pattern {val}", "{key} does not match the pattern {val}"},
+			"nlike":       {"{key} does not match the pattern {val}", "{key} matches the pattern {val}"},
+			"ilike":       {"{key} matches the pattern {val} ignoring case", "{key} does not match the pattern {val} ignoring case"},
+			"nilike":      {"{key} does not match the pattern {val} ignoring case", "{key} matches the pattern {val} ignoring case"},
+			"glob":        {"{key} matches the pattern {val}", "{key} does not match the pattern {val}"},
+			"iglob":       {"{key} matches the pattern {val} ignoring case", "{key} does not match the pattern {val} ignoring case"},
+			"levenshtein": {"{key} is similar to {val}", "{key} is not similar to {val}"},
+			"damerau":     {"{key} is similar to {val}", "{key} is not similar to {val}"},
+			"jarowinkler": {"{key} is similar to {val}", "{key} is not similar to {val}"},
+		},
+		andTop:     renderJoin{sep: ", and "},
+		andNested:  renderJoin{prefix: "both ", sep: " and "},
+		orTop:      renderJoin{prefix: "either ", sep: " or "},
+		orNested:   renderJoin{prefix: "either ", sep: " or "},
+		not:        "it is not the case that ",
+		quote:      [2]string{"'", "'"},
+		listSep:    ", ",
+		null:       "null",
+		circle:     "{radius} m of ({lat}, {lon})",
+		area:       "the given area",
+		distance:   " (edit distance at most {n})",
+		similarity: " (similarity at least {n})",
+	},
+	"zh-cn": {
+		ops: map[string][2]string{
+			"=":           {"{key}等于{val}", "{key}不等于{val}"},
+			"!=":          {"{key}不等于{val}", "{key}等于{val}"},
+			">":           {"{key}大于{val}", "{key}不大于{val}"},
+			"<":           {"{key}小于{val}", "{key}不小于{val}"},
+			">=":          {"{key}不小于{val}", "{key}小于{val}"},
+			"<=":          {"{key}不大于{val}", "{key}大于{val}"},
+			"in":          {"{key}为{val}之一", "{key}不是{val}中的任何一个"},
+			"nin":         {"{key}不是{val}中的任何一个", "{key}为{val}之一"},
+			"regex":       {"{key}匹配正则表达式{val}", "{key}不匹配正则表达式{val}"},
+			"empty":       {"{key}为空", "{key}不为空"},
+			"nempty":      {"{key}不为空", "{key}为空"},
+			"between":     {"{key}在{val}范围内", "{key}在{val}范围外"},
+			"intersect":   {"{key}包含{val}中的任一个", "{key}不包含{val}中的任何一个"},
+			"subset":      {"{key}的值都在{val}中", "{key}有不在{val}中的值"},
+			"superset":    {"{key}包含{val}的全部", "{key}没有包含{val}的全部"},
+			"exists":      {"{key}存在", "{key}不存在"},
+			"notexists":   {"{key}不存在", "{key}存在"},
+			"null":        {"{key}为null", "{key}不为null"},
+			"notnull":     {"{key}不为null", "{key}为null"},
+			"blank":       {"{key}为空白", "{key}不为空白"},
+			"notblank":    {"{key}不为空白", "{key}为空白"},
+			"ipin":        {"{key}在网段{val}中", "{key}不在网段{val}中"},
+			"ipnin":       {"{key}不在网段{val}中", "{key}在网段{val}中"},
+			"ver=":        {"{key}的版本为{val}", "{key}的版本不为{val}"},
+			"ver>":        {"{key}的版本高于{val}", "{key}的版本不高于{val}"},
+			"ver>=":       {"{key}的版本不低于{val}", "{key}的版本低于{val}"},
+			"ver<":        {"{key}的版本低于{val}", "{key}的版本不低于{val}"},
+			"ver<=":       {"{key}的版本不高于{val}", "{key}的版本高于{val}"},
+			"verbetween":  {"{key}的版本在{val}范围内", "{key}的版本在{val}范围外"},
+			"semver":      {"{key}的版本满足{val}", "{key}的版本不满足{val}"},
+			"georadius":   {"{key}位于{val}以内", "{key}不在{val}以内"},
+			"geopolygon":  {"{key}位于{val}内", "{key}不在{val}内"},
+			"format":      {"{key}是有效的{val}", "{key}不是有效的{val}"},
+			"like":        {"{key}匹配模式{val}", "{key}不匹配模式{val}"},
+			"nlike":       {"{key}不匹配模式{val}", "{key}匹配模式{val}"},
+			"ilike":       {"{key}匹配模式{val}（忽略大小写）", "{key}不匹配模式{val}（忽略大小写）"},
+			"nilike":      {"{key}不匹配模式{val}（忽略大小写）", "{key}匹配模式{val}（忽略大小写）"},
+			"glob":        {"{key}匹配模式{val}", "{key}不匹配模式{val}"},
+			"iglob":       {"{key}匹配模式{val}（忽略大小写）", "{key}不匹配模式{val}（忽略大小写）"},
+			"levenshtein": {"{key}与{val}相似", "{key}与{val}不相似"},
+			"damerau":     {"{key}与{val}相似", "{key}与{val}不相似"},
+			"jarowinkler": {"{key}与{val}相似", "{key}与{val}不相似"},
+		},
+		andTop:     renderJoin{sep: "，且"},
+		andNested:  renderJoin{prefix: "（", sep: "且", suffix: "）"},
+		orTop:      renderJoin{sep: "，或"},
+		orNested:   renderJoin{prefix: "（", sep: "或", suffix: "）"},
+		not:        "并非",
+		quote:      [2]string{"“", "”"},
+		listSep:    "、",
+		null:       "null",
+		circle:     "距({lat}, {lon}) {radius}米",
+		area:       "指定区域",
+		distance:   "（编辑距离不超过{n}）",
+		similarity: "（相似度不低于{n}）",
+	},
+}
+
+// NewRenderer 指定语言的Renderer，labels可以为nil
+func NewRenderer(locale string, labels map[string]string) (*Renderer, error) {
+	r := &Renderer{Locale: locale, Labels: labels}
+	if _, err := r.locale(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// locale 先精确匹配，再按语言前缀匹配，如 zh 对应 zh-CN，en-US 对应 en
+func (r *Renderer) locale() (*renderLocale, error) {
+	name := strings.ToLower(strings.ReplaceAll(r.Locale, "_", "-"))
+	if name == EmptyStr {
+		name = "en"
+	}
+	if l, ok := renderLocales[name]; ok {
+		return l, nil
+	}
+	lang, _, _ := strings.Cut(name, "-")
+	for _, key := range sortedKeys(renderLocales) {
+		if prefix, _, _ := strings.Cut(key, "-"); prefix == lang {
+			return renderLocales[key], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown locale %q", r.Locale)
+}
+
+// Render Rules的描述，没有逻辑表达式时子规则以and连接
+func (r *Renderer) Render(rs *Rules) (string, error) {
+	l, err := r.locale()
+	if err != nil {
+		return EmptyStr, err
+	}
+	byID := make(map[int]*Rule, len(rs.Rules))
+	ids := make([]string, 0, len(rs.Rules))
+	for _, rule := range rs.Rules {
+		byID[rule.ID] = rule
+		ids = append(ids, strconv.Itoa(rule.ID))
+	}
+	logic := rs.Logic
+	if logic == EmptyStr {
+		logic = strings.Join(ids, " and ")
+	}
+	head := logicToTree(logic)
+	if head == nil {
+		return EmptyStr, fmt.Errorf("empty rules")
+	}
+	return r.renderNode(l, head, byID, true, false)
+}
+
+// RenderRule 单条子规则的描述
+func (r *Renderer) RenderRule(rule *Rule) (string, error) {
+	l, err := r.locale()
+	if err != nil {
+		return EmptyStr, err
+	}
+	return r.renderRule(l, rule, false)
+}
+
+// renderNode top为true时是最外层的条件，negative为true时描述其否定
+func (r *Renderer) renderNode(l *renderLocale, node *Node, byID map[int]*Rule, top, negative bool) (string, error) {
+	if node.Leaf {
+		id, _ := strconv.Atoi(node.Expr)
+		rule, ok := byID[id]
+		if !ok {
+			return EmptyStr, fmt.Errorf("rule %d in logic not found", id)
+		}
+		return r.renderRule(l, rule, negative)
+	}
+	if len(node.Children) == 0 {
+		// 整体被括号包住的表达式
+		inner := logicToTree(strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(node.Expr), "("), ")")))
+		if inner == nil || inner.Expr == node.Expr {
+			return EmptyStr, fmt.Errorf("invalid logic expression %q", node.Expr)
+		}
+		return r.renderNode(l, inner, byID, top, negative)
+	}
+	if node.ChildrenOp == string(OperatorNot) {
+		child := node.Children[0]
+		if child.Leaf || len(child.Children) > 0 && child.ChildrenOp == string(OperatorNot) {
+			return r.renderNode(l, child, byID, top, !negative)
+		}
+		text, err := r.renderNode(l, child, byID, false, false)
+		if err != nil {
+			return EmptyStr, err
+		}
+		if negative {
+			return text, nil
+		}
+		return l.not + text, nil
+	}
+	items := make([]string, 0, len(node.Children))
+	for _, child := range node.Children {
+		text, err := r.renderNode(l, child, byID, false, false)
+		if err != nil {
+			return EmptyStr, err
+		}
+		items = append(items, text)
+	}
+	var join renderJoin
+	switch {
+	case node.ChildrenOp == string(OperatorAnd) && top:
+		join = l.andTop
+	case node.ChildrenOp == string(OperatorAnd):
+		join = l.andNested
+	case top:
+		join = l.orTop
+	default:
+		join = l.orNested
+	}
+	text := join.join(items)
+	if negative {
+		return l.not + text, nil
+	}
+	return text, nil
+}
+
+// renderRule 子规则的描述，negative为true时用否定的说法
+func (r *Renderer) renderRule(l *renderLocale, rule *Rule, negative bool) (string, error) {
+	op := rule.Op
+	if display, ok := operatorAliases[op]; ok {
+		op = display
+	}
+	phrases, ok := l.ops[op]
+	if !ok {
+		return EmptyStr, fmt.Errorf("rule %d: unsupported operator %q", rule.ID, rule.Op)
+	}
+	phrase := phrases[0]
+	if negative {
+		phrase = phrases[1]
+	}
+	label := rule.Key
+	if name, ok := r.Labels[rule.Key]; ok {
+		label = name
+	}
+	return strings.NewReplacer("{key}", label, "{val}", r.renderVal(l, op, rule)).Replace(phrase), nil
+}
+
+// renderVal 按算符描述子规则的值
+func (r *Renderer) renderVal(l *renderLocale, op string, rule *Rule) string {
+	switch op {
+	case "in", "nin", "intersect", "subset", "superset", "ipin", "ipnin":
+		var items []string
+		if str, ok := rule.Val.(string); ok {
+			for _, o := range strings.Split(str, ",") {
+				items = append(items, r.renderScalar(l, op, strings.TrimSpace(o)))
+			}
+		} else if elements, ok := listElements(rule.Val); ok {
+			for _, o := range elements {
+				items = append(items, r.renderScalar(l, op, o))
+			}
+		}
+		return strings.Join(items, l.listSep)
+	case "between", "verbetween", "semver", "ver=", "ver>", "ver>=", "ver<", "ver<=", "format":
+		return fmt.Sprint(rule.Val)
+	case "georadius":
+		circle, ok := rule.compiledVal().(*geoCircle)
+		if !ok {
+			return fmt.Sprint(rule.Val)
+		}
+		return strings.NewReplacer(
+			"{radius}", formatFloat(circle.radius),
+			"{lat}", formatFloat(circle.center.lat),
+			"{lon}", formatFloat(circle.center.lon),
+		).Replace(l.circle)
+	case "geopolygon":
+		return l.area
+	case "levenshtein", "damerau", "jarowinkler":
+		ft, ok := rule.compiledVal().(*fuzzyTargets)
+		if !ok {
+			return fmt.Sprint(rule.Val)
+		}
+		items := make([]string, 0, len(ft.targets))
+		for _, target := range ft.targets {
+			items = append(items, l.quote[0]+target+l.quote[1])
+		}
+		if ft.distance >= 0 {
+			return strings.Join(items, l.listSep) + strings.ReplaceAll(l.distance, "{n}", strconv.Itoa(ft.distance))
+		}
+		return strings.Join(items, l.listSep) + strings.ReplaceAll(l.similarity, "{n}", formatFloat(ft.similarity))
+	default:
+		return r.renderScalar(l, op, rule.Val)
+	}
+}
+
+// renderScalar 字符串加引号，数字去掉多余的0，IP不加引号
+func (r *Renderer) renderScalar(l *renderLocale, op string, v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return l.null
+	case string:
+		if op == "ipin" || op == "ipnin" {
+			return t
+		}
+		return l.quote[0] + t + l.quote[1]
+	default:
+		if isNumber(v) {
+			return formatFloat(formatNumber(v))
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
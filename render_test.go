@@ -0,0 +1,92 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	rs, err := NewRulesWithDSL(`Grade = 3 and not Sex = "male" and (Score.Math >= 90 or Score.Physic >= 90)`)
+	assert.Nil(t, err)
+	labels := map[string]string{"Score.Math": "Math score", "Score.Physic": "Physic score"}
+
+	en, err := NewRenderer("en", labels)
+	assert.Nil(t, err)
+	text, err := en.Render(rs)
+	assert.Nil(t, err)
+	assert.Equal(t, "Grade equals 3, and Sex is not 'male', and either Math score is at least 90 or Physic score is at least 90", text)
+
+	zh, err := NewRenderer("zh-CN", map[string]string{"Grade": "年级", "Sex": "性别", "Score.Math": "数学成绩", "Score.Physic": "物理成绩"})
+	assert.Nil(t, err)
+	text, err = zh.Render(rs)
+	assert.Nil(t, err)
+	assert.Equal(t, "年级等于3，且性别不等于“male”，且（数学成绩不小于90或物理成绩不小于90）", text)
+}
+
+func TestRenderer_Logic(t *testing.T) {
+	rules := []*Rule{
+		{Op: "@", Key: "Sex", Val: []interface{}{"male", "female"}, ID: 1},
+		{Op: "between", Key: "Age", Val: "[18, 60)", ID: 2},
+		{Op: "exists", Key: "Email", ID: 3},
+		{Op: "format", Key: "Email", Val: "email", ID: 4},
+	}
+	cases := map[string][2]string{
+		"":                       {"Sex is one of 'male', 'female', and Age is within [18, 60), and Email is present, and Email is a valid email", "Sex为“male”、“female”之一，且Age在[18, 60)范围内，且Email存在，且Email是有效的email"},
+		"1 or 2":                 {"either Sex is one of 'male', 'female' or Age is within [18, 60)", "Sex为“male”、“female”之一，或Age在[18, 60)范围内"},
+		"not (1 or 2)":           {"it is not the case that either Sex is one of 'male', 'female' or Age is within [18, 60)", "并非（Sex为“male”、“female”之一或Age在[18, 60)范围内）"},
+		"not (not 3)":            {"Email is present", "Email存在"},
+		"1 or (3 and not 4)":     {"either Sex is one of 'male', 'female' or both Email is present and Email is not a valid email", "Sex为“male”、“female”之一，或（Email存在且Email不是有效的email）"},
+		"(2)":                    {"Age is within [18, 60)", "Age在[18, 60)范围内"},
+		"not 2 and not (3 or 4)": {"Age is outside [18, 60), and it is not the case that either Email is present or Email is a valid email", "Age在[18, 60)范围外，且并非（Email存在或Email是有效的email）"},
+	}
+	en := &Renderer{}
+	zh := &Renderer{Locale: "zh"}
+	for logic, expect := range cases {
+		rs, err := NewRulesWithArrayAndLogic(rules, logic)
+		if !assert.Nil(t, err, logic) {
+			continue
+		}
+		text, err := en.Render(rs)
+		assert.Nil(t, err)
+		assert.Equal(t, expect[0], text, logic)
+		text, err = zh.Render(rs)
+		assert.Nil(t, err)
+		assert.Equal(t, expect[1], text, logic)
+	}
+}
+
+func TestRenderer_RenderRule(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "georadius", "key": "Location", "val": {"lat": 31.23, "lon": 121.47, "radius": 5000}, "id": 1},
+	{"op": "levenshtein", "key": "Name", "val": {"targets": ["Chris", "Kris"], "distance": 1}, "id": 2},
+	{"op": "jarowinkler", "key": "Name", "val": {"target": "Chris", "similarity": 0.9}, "id": 3},
+	{"op": "ipin", "key": "IP", "val": "10.0.0.0/8, 192.168.1.1", "id": 4},
+	{"op": "semver", "key": "Version", "val": "^1.2", "id": 5},
+	{"op": "=", "key": "Deleted", "val": null, "id": 6}
+	]`), "")
+	assert.Nil(t, err)
+	en := &Renderer{Locale: "en-US"}
+	zh := &Renderer{Locale: "zh_CN"}
+	expects := [][2]string{
+		{"Location is within 5000 m of (31.23, 121.47)", "Location位于距(31.23, 121.47) 5000米以内"},
+		{"Name is similar to 'Chris', 'Kris' (edit distance at most 1)", "Name与“Chris”、“Kris”（编辑距离不超过1）相似"},
+		{"Name is similar to 'Chris' (similarity at least 0.9)", "Name与“Chris”（相似度不低于0.9）相似"},
+		{"IP is an IP in 10.0.0.0/8, 192.168.1.1", "IP在网段10.0.0.0/8、192.168.1.1中"},
+		{"Version is a version satisfying ^1.2", "Version的版本满足^1.2"},
+		{"Deleted equals null", "Deleted等于null"},
+	}
+	for i, rule := range rs.Rules {
+		text, err := en.RenderRule(rule)
+		assert.Nil(t, err)
+		assert.Equal(t, expects[i][0], text)
+		text, err = zh.RenderRule(rule)
+		assert.Nil(t, err)
+		assert.Equal(t, expects[i][1], text)
+	}
+
+	_, err = NewRenderer("fr", nil)
+	assert.NotNil(t, err)
+	_, err = en.RenderRule(&Rule{Op: "~", Key: "A", ID: 1})
+	assert.NotNil(t, err)
+}
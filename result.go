@@ -0,0 +1,94 @@
+package ruler
+
+/**
+  结构化的匹配结果，子规则的结果有序，可直接json序列化作为接口返回：
+  {"fit": false, "name": "student", "outcomes": [{"id": 1, "key": "Grade", "op": "=", "expected": 3, "actual": 4, "matched": false, "msg": "Grade not match", "reason": true}, ...]}
+  1. outcomes按子规则在逻辑表达式中出现的顺序，逻辑表达式为空或未引用的子规则按Rules中的顺序排在后面
+  2. msg为子规则自身结果对应的提示：不满足时为负提示，满足时为正提示（没有passMsg时为空）
+  3. reason标记Fit返回的提示中的子规则，即导致fit为该值的子规则
+*/
+
+// Result 结构化的匹配结果
+type Result struct {
+	Fit      bool       `json:"fit"`            // 匹配结果
+	Name     string     `json:"name,omitempty"` // 规则名称
+	Outcomes []*Outcome `json:"outcomes"`       // 子规则的结果，有序
+}
+
+// Outcome 子规则的匹配结果
+type Outcome struct {
+	ID       int         `json:"id"`                 // 子规则ID
+	Key      string      `json:"key"`                // 子规则key
+	Op       string      `json:"op"`                 // 算符
+	Expected interface{} `json:"expected"`           // 子规则的值
+	Actual   interface{} `json:"actual"`             // 取到的实际值，key不存在时为nil
+	Matched  bool        `json:"matched"`            // 子规则是否满足
+	Msg      string      `json:"msg,omitempty"`      // 子规则结果对应的提示
+	Severity Severity    `json:"severity,omitempty"` // 子规则的级别
+	Reason   bool        `json:"reason"`             // 是否是导致fit为该值的子规则
+}
+
+// FitResult Rules匹配结构体，返回结构化的结果
+func (rs *Rules) FitResult(o interface{}) *Result {
+	return rs.result(rs.structGetter(rs.Adapter, o), EmptyStr)
+}
+
+// FitWithMapResult Rules匹配map，返回结构化的结果
+func (rs *Rules) FitWithMapResult(o map[string]interface{}) *Result {
+	return rs.result(mapGetter(o), EmptyStr)
+}
+
+// FitResultLocale Rules匹配结构体，返回结构化的结果，提示使用指定语言
+func (rs *Rules) FitResultLocale(o interface{}, locale string) *Result {
+	return rs.result(rs.structGetter(rs.Adapter, o), locale)
+}
+
+// FitWithMapResultLocale Rules匹配map，返回结构化的结果，提示使用指定语言
+func (rs *Rules) FitWithMapResultLocale(o map[string]interface{}, locale string) *Result {
+	return rs.result(mapGetter(o), locale)
+}
+
+func (rs *Rules) result(get valueGetter, locale string) *Result {
+	results := make(map[int]Truth)
+	answer, tips, values, _ := rs.judge(get, false, locale, nil, results)
+	result := &Result{Fit: answer == TruthTrue, Name: rs.Name, Outcomes: make([]*Outcome, 0, len(rs.Rules))}
+	for _, rule := range rs.orderedRules() {
+		v := values[rule.ID]
+		matched := results[rule.ID] == TruthTrue
+		outcome := &Outcome{ID: rule.ID, Key: rule.Key, Op: rule.Op, Expected: rule.Val, Actual: v, Matched: matched, Severity: rule.Severity}
+		if match, ok := v.(*FuzzyMatch); ok {
+			outcome.Actual = match.Value
+		}
+		if !matched || rule.hasPassMsg() {
+			outcome.Msg = rs.tipIn(rule, v, locale, matched)
+		}
+		_, outcome.Reason = tips[rule.ID]
+		result.Outcomes = append(result.Outcomes, outcome)
+	}
+	return result
+}
+
+// orderedRules 子规则按在逻辑表达式中出现的顺序，未引用的按原顺序排在后面
+func (rs *Rules) orderedRules() []*Rule {
+	ids, err := GetRuleIDsByLogicExpression(rs.Logic)
+	if err != nil || len(ids) == 0 {
+		return rs.Rules
+	}
+	byID := make(map[int]*Rule, len(rs.Rules))
+	for _, rule := range rs.Rules {
+		byID[rule.ID] = rule
+	}
+	ordered := make([]*Rule, 0, len(rs.Rules))
+	for _, id := range ids {
+		if rule, ok := byID[id]; ok {
+			ordered = append(ordered, rule)
+			delete(byID, id)
+		}
+	}
+	for _, rule := range rs.Rules {
+		if _, ok := byID[rule.ID]; ok {
+			ordered = append(ordered, rule)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,67 @@
+package ruler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_FitResult(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match", "passMsg": "Grade is {{.Actual}}"},
+	{"op": "=", "key": "Sex", "val": "male", "id": 2, "msg": "not male"},
+	{"op": ">=", "key": "Score.Math", "val": 90, "id": 3, "msg": "Math not so well", "severity": "warning"},
+	{"op": ">=", "key": "Score.Physic", "val": 90, "id": 4, "msg": "Physic not so well"},
+	{"op": "nempty", "key": "Name", "id": 5, "msg": "no name"}
+	]`), "(4 or 3) and not 2 and 1")
+	assert.Nil(t, err)
+	rs.Name = "student"
+
+	type Exams struct {
+		Math   int
+		Physic int
+	}
+	type Student struct {
+		Grade int
+		Sex   string
+		Score *Exams
+	}
+	result := rs.FitResult(&Student{Grade: 3, Sex: "female", Score: &Exams{Math: 88, Physic: 91}})
+	assert.True(t, result.Fit)
+	var ids []int
+	for _, outcome := range result.Outcomes {
+		ids = append(ids, outcome.ID)
+	}
+	// 按逻辑表达式中出现的顺序，未引用的子规则排在后面
+	assert.Equal(t, []int{4, 3, 2, 1, 5}, ids)
+	assert.Equal(t, &Outcome{ID: 1, Key: "Grade", Op: "=", Expected: float64(3), Actual: 3, Matched: true, Msg: "Grade is 3", Reason: true}, result.Outcomes[3])
+	assert.Equal(t, &Outcome{ID: 3, Key: "Score.Math", Op: ">=", Expected: float64(90), Actual: 88, Msg: "Math not so well", Severity: SeverityWarning}, result.Outcomes[1])
+	assert.Equal(t, &Outcome{ID: 5, Key: "Name", Op: "nempty", Msg: "no name"}, result.Outcomes[4])
+
+	_, tips := rs.Fit(&Student{Grade: 3, Sex: "female", Score: &Exams{Math: 88, Physic: 91}})
+	for _, outcome := range result.Outcomes {
+		_, ok := tips[outcome.ID]
+		assert.Equal(t, ok, outcome.Reason)
+	}
+
+	result = rs.FitWithMapResult(map[string]interface{}{"Grade": 4, "Sex": "male"})
+	assert.False(t, result.Fit)
+	data, err := json.Marshal(result)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"fit":false,"name":"student","outcomes":[`+
+		`{"id":4,"key":"Score.Physic","op":"\u003e=","expected":90,"actual":null,"matched":false,"msg":"Physic not so well","reason":true},`+
+		`{"id":3,"key":"Score.Math","op":"\u003e=","expected":90,"actual":null,"matched":false,"msg":"Math not so well","severity":"warning","reason":false},`+
+		`{"id":2,"key":"Sex","op":"=","expected":"male","actual":"male","matched":true,"reason":false},`+
+		`{"id":1,"key":"Grade","op":"=","expected":3,"actual":4,"matched":false,"msg":"Grade not match","reason":false},`+
+		`{"id":5,"key":"Name","op":"nempty","expected":null,"actual":null,"matched":false,"msg":"no name","reason":false}]}`, string(data))
+}
+
+func TestRules_FitResultFuzzy(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[{"op": "levenshtein", "key": "Name", "val": {"target": "Chris", "distance": 1}, "id": 1}]`), "")
+	assert.Nil(t, err)
+	result := rs.FitWithMapResult(map[string]interface{}{"Name": "Chriss"})
+	assert.True(t, result.Fit)
+	assert.Equal(t, "Chriss", result.Outcomes[0].Actual)
+	assert.True(t, result.Outcomes[0].Matched)
+}
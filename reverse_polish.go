@@ -8,7 +8,17 @@ import (
 	"strings"
 )
 
-func (*Rules) calculateExpression(expr string, values map[int]bool) (bool, error) {
+func (rs *Rules) calculateExpression(expr string, values map[int]bool) (bool, error) {
+	var tri = make(map[int]Truth, len(values))
+	for id, val := range values {
+		tri[id] = truthOf(val)
+	}
+	result, err := rs.calculateExpressionTri(expr, tri)
+	return result == TruthTrue, err
+}
+
+// calculateExpressionTri 按Kleene三值逻辑计算逆波兰表达式
+func (*Rules) calculateExpressionTri(expr string, values map[int]Truth) (Truth, error) {
 	listExpr := strings.Split(expr, " ")
 	stackNum := list.New()
 	stackOp := list.New()
@@ -17,7 +27,7 @@ func (*Rules) calculateExpression(expr string, values map[int]bool) (bool, error
 	patternNum := "^\\d*$"
 	regexNum, err := regexp.Compile(patternNum)
 	if err != nil {
-		return false, err
+		return TruthFalse, err
 	}
 
 	for _, c := range listExpr {
@@ -31,7 +41,7 @@ func (*Rules) calculateExpression(expr string, values map[int]bool) (bool, error
 				lastOpRaw := stackOp.Back().Value
 				lastOp, ok = lastOpRaw.(string)
 				if !ok {
-					return false, errors.New("error type of operator")
+					return TruthFalse, errors.New("error type of operator")
 				}
 			}
 			if isOpBiggerInLogic(c, lastOp) || c == "(" {
@@ -50,7 +60,7 @@ func (*Rules) calculateExpression(expr string, values map[int]bool) (bool, error
 					}
 					lastOp, ok := lastOpRaw.Value.(string)
 					if !ok {
-						return false, errors.New("error type of operator")
+						return TruthFalse, errors.New("error type of operator")
 					}
 					if isOpBiggerInLogic(c, lastOp) {
 						break
@@ -62,11 +72,11 @@ func (*Rules) calculateExpression(expr string, values map[int]bool) (bool, error
 				if c == ")" {
 					// delete "("
 					if stackOp.Back() == nil {
-						return false, errors.New("error expression to calculate: " + expr)
+						return TruthFalse, errors.New("error expression to calculate: " + expr)
 					}
 					if strBracket, ok := stackOp.Back().Value.(string); ok {
 						if strBracket != "(" {
-							return false, errors.New("error expression to calculate: " + expr)
+							return TruthFalse, errors.New("error expression to calculate: " + expr)
 						}
 					}
 					stackOp.Remove(stackOp.Back())
@@ -86,10 +96,10 @@ func (*Rules) calculateExpression(expr string, values map[int]bool) (bool, error
 		// judge stackOp char valid: not ( or )
 		if strOp, ok := stackOp.Back().Value.(string); ok {
 			if strOp == "(" || strOp == ")" {
-				return false, errors.New("error expression to calculate: " + expr)
+				return TruthFalse, errors.New("error expression to calculate: " + expr)
 			}
 		} else {
-			return false, errors.New("error expression to calculate: " + expr)
+			return TruthFalse, errors.New("error expression to calculate: " + expr)
 		}
 
 		stackNum.PushBack(stackOp.Back().Value)
@@ -102,17 +112,17 @@ func (*Rules) calculateExpression(expr string, values map[int]bool) (bool, error
 		itemRaw := stackNum.Front().Value
 		item, ok := itemRaw.(string)
 		if !ok {
-			return false, errors.New("error type in stack number")
+			return TruthFalse, errors.New("error type in stack number")
 		}
 		if regexNum.MatchString(item) {
 			index, err := strconv.Atoi(item)
 			if err != nil {
-				return false, err
+				return TruthFalse, err
 			}
 			if val, ok := values[index]; ok {
 				stackOp.PushBack(val)
 			} else {
-				return false, errors.New("empty operand value in map: " + item)
+				return TruthFalse, errors.New("empty operand value in map: " + item)
 			}
 
 		} else {
@@ -120,41 +130,41 @@ func (*Rules) calculateExpression(expr string, values map[int]bool) (bool, error
 			if numOfOperandInLogic(item) == 2 {
 				operandBRaw := stackOp.Back()
 				if operandBRaw == nil {
-					return false, errors.New("error expression to calculate: " + expr)
+					return TruthFalse, errors.New("error expression to calculate: " + expr)
 				}
-				operandB, ok := operandBRaw.Value.(bool)
+				operandB, ok := operandBRaw.Value.(Truth)
 				if !ok {
-					return false, errors.New("error type of operator")
+					return TruthFalse, errors.New("error type of operator")
 				}
 				stackOp.Remove(stackOp.Back())
 				operandARaw := stackOp.Back()
 				if operandARaw == nil {
-					return false, errors.New("error expression to calculate: " + expr)
+					return TruthFalse, errors.New("error expression to calculate: " + expr)
 				}
-				operandA, ok := operandARaw.Value.(bool)
+				operandA, ok := operandARaw.Value.(Truth)
 				if !ok {
-					return false, errors.New("error type of operator")
+					return TruthFalse, errors.New("error type of operator")
 				}
 				stackOp.Remove(stackOp.Back())
-				computeOutput, err := computeOneInLogic(item, []bool{operandA, operandB})
+				computeOutput, err := computeOneInLogicTri(item, []Truth{operandA, operandB})
 				if err != nil {
-					return false, errors.New("error in one compute")
+					return TruthFalse, errors.New("error in one compute")
 				}
 				stackOp.PushBack(computeOutput)
 			}
 			if numOfOperandInLogic(item) == 1 {
 				operandBRaw := stackOp.Back()
 				if operandBRaw == nil {
-					return false, errors.New("error expression to calculate: " + expr)
+					return TruthFalse, errors.New("error expression to calculate: " + expr)
 				}
-				operandB, ok := operandBRaw.Value.(bool)
+				operandB, ok := operandBRaw.Value.(Truth)
 				if !ok {
-					return false, errors.New("error type of operator")
+					return TruthFalse, errors.New("error type of operator")
 				}
 				stackOp.Remove(stackOp.Back())
-				computeOutput, err := computeOneInLogic(item, []bool{operandB})
+				computeOutput, err := computeOneInLogicTri(item, []Truth{operandB})
 				if err != nil {
-					return false, errors.New("error in one compute")
+					return TruthFalse, errors.New("error in one compute")
 				}
 				stackOp.PushBack(computeOutput)
 			}
@@ -163,11 +173,11 @@ func (*Rules) calculateExpression(expr string, values map[int]bool) (bool, error
 	}
 
 	if stackOp.Back() == nil || stackOp.Len() != 1 {
-		return false, errors.New("error expression to calculate: " + expr)
+		return TruthFalse, errors.New("error expression to calculate: " + expr)
 	}
-	result, ok := stackOp.Back().Value.(bool)
+	result, ok := stackOp.Back().Value.(Truth)
 	if !ok {
-		return false, errors.New("error type in final result")
+		return TruthFalse, errors.New("error type in final result")
 	}
 	return result, nil
 }
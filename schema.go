@@ -0,0 +1,206 @@
+package ruler
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+/**
+  规则文档的JSON Schema（draft 2020-12），供前端编辑器、评审工具等不调用Go的场景校验规则：
+  1. $defs中rule、rules、rulesList分别描述子规则、Rules文档和RulesList文档
+  2. op限定为ValidAtomOperatorsDisplay及其别名，val按算符约束，见operatorValSchemas
+  3. 区间、版本约束、正则等字符串的语法只在构造时校验
+  生成结果同时发布为仓库根目录的rules.schema.json，由测试保证与代码一致
+*/
+
+// operatorAliases 算符的别名
+var operatorAliases = map[string]string{
+	"eq": "=", "gt": ">", "lt": "<", "gte": ">=", "lte": "<=", "neq": "!=",
+	"@": "in", "!@": "nin", "^$": "regex", "<<": "between", "@@": "intersect",
+	"0": "empty", "1": "nempty",
+}
+
+type schema map[string]interface{}
+
+var (
+	schemaAny     = schema{}
+	schemaString  = schema{"type": "string"}
+	schemaScalar  = schema{"type": []string{"string", "number", "boolean", "null"}}
+	schemaOrdered = schema{"type": []string{"number", "string"}}
+	// 列表的元素不能为null，见newValueSet
+	schemaList = schema{"oneOf": []schema{
+		{"type": "string", "description": "comma separated values"},
+		{"type": "array", "items": schema{"type": []string{"string", "number", "boolean"}}},
+	}}
+	schemaMsgs     = schema{"type": "object", "additionalProperties": schemaString}
+	schemaInterval = schema{"type": "string", "pattern": `^\s*[\[(]`}
+	schemaIPList   = schema{"oneOf": []schema{
+		{"type": "string", "description": "comma separated IP or CIDR"},
+		{"type": "array", "items": schemaString},
+	}}
+	schemaGeoRadius = schema{"oneOf": []schema{
+		{"type": "string", "description": "json of the object"},
+		{"type": "object", "required": []string{"radius"}, "properties": schema{"radius": schema{"type": "number", "minimum": 0}}},
+	}}
+	schemaGeoPolygon = schema{"oneOf": []schema{
+		{"type": "string", "description": "json of the object"},
+		{"type": "object", "required": []string{"type"}, "properties": schema{"type": schema{"enum": []string{"Polygon", "MultiPolygon", "Feature"}}}},
+	}}
+)
+
+// schemaFuzzy 模糊匹配算符的值，jarowinkler只能用similarity
+func schemaFuzzy(distance bool) schema {
+	properties := schema{
+		"target":     schemaString,
+		"targets":    schema{"type": "array", "items": schemaString, "minItems": 1},
+		"ignorecase": schema{"type": "boolean"},
+		"similarity": schema{"type": "number", "minimum": 0, "maximum": 1},
+	}
+	threshold := []schema{{"required": []string{"similarity"}}}
+	if distance {
+		properties["distance"] = schema{"type": "integer", "minimum": 0}
+		threshold = append(threshold, schema{"required": []string{"distance"}})
+	}
+	return schema{"oneOf": []schema{
+		{"type": "string", "description": "json of the object"},
+		{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+			"allOf": []schema{
+				{"anyOf": []schema{{"required": []string{"target"}}, {"required": []string{"targets"}}}},
+				{"oneOf": threshold},
+			},
+		},
+	}}
+}
+
+// operatorValSchemas 每个算符的val约束，与ValidAtomOperatorsDisplay一一对应
+var operatorValSchemas = map[string]schema{
+	"=": schemaScalar, "!=": schemaScalar,
+	">": schemaOrdered, "<": schemaOrdered, ">=": schemaOrdered, "<=": schemaOrdered,
+	"in": schemaList, "nin": schemaList, "intersect": schemaList, "subset": schemaList, "superset": schemaList,
+	"regex": {"type": "string", "format": "regex"},
+	"empty": schemaAny, "nempty": schemaAny, "exists": schemaAny, "notexists": schemaAny,
+	"null": schemaAny, "notnull": schemaAny, "blank": schemaAny, "notblank": schemaAny,
+	"between":    schemaInterval,
+	"ipin":       schemaIPList,
+	"ipnin":      schemaIPList,
+	"ver=":       schemaString,
+	"ver>":       schemaString,
+	"ver>=":      schemaString,
+	"ver<":       schemaString,
+	"ver<=":      schemaString,
+	"verbetween": schemaInterval,
+	"semver":     schemaString,
+	"georadius":  schemaGeoRadius,
+	"geopolygon": schemaGeoPolygon,
+	"format":     {"enum": formatNames()},
+	"like":       schemaString, "nlike": schemaString, "ilike": schemaString, "nilike": schemaString,
+	"glob": schemaString, "iglob": schemaString,
+	"levenshtein": schemaFuzzy(true),
+	"damerau":     schemaFuzzy(true),
+	"jarowinkler": schemaFuzzy(false),
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(formatCheckers))
+	for name := range formatCheckers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSONSchema 生成规则文档的JSON Schema
+func JSONSchema() ([]byte, error) {
+	var ops []string
+	var conditions []schema
+	for _, op := range ValidAtomOperatorsDisplay {
+		names := []string{op}
+		for alias, target := range operatorAliases {
+			if target == op {
+				names = append(names, alias)
+			}
+		}
+		sort.Strings(names[1:])
+		ops = append(ops, names...)
+		conditions = append(conditions, schema{
+			"if":   schema{"properties": schema{"op": schema{"enum": names}}, "required": []string{"op"}},
+			"then": schema{"properties": schema{"val": operatorValSchemas[op]}},
+		})
+	}
+	rule := schema{
+		"type": "object",
+		"properties": schema{
+			"op":       schema{"enum": ops},
+			"key":      schemaString,
+			"val":      schemaAny,
+			"id":       schema{"type": "integer", "minimum": 0},
+			"msg":      schemaString,
+			"msgs":     schemaMsgs,
+			"passMsg":  schemaString,
+			"passMsgs": schemaMsgs,
+			"severity": schema{"enum": []Severity{SeverityError, SeverityWarning, SeverityInfo}},
+		},
+		"required":             []string{"op", "key"},
+		"additionalProperties": false,
+		"allOf":                conditions,
+	}
+	rulesProperties := func(top bool) schema {
+		properties := schema{
+			"name":  schemaString,
+			"msg":   schemaString,
+			"logic": schemaString,
+			"val":   schemaAny,
+			"msgs":  schemaMsgs,
+			"rules": schema{"type": "array", "items": schema{"$ref": "#/$defs/rule"}},
+		}
+		if top {
+			properties["version"] = schema{"const": DocumentVersion}
+			properties["kind"] = schema{"const": kindRules}
+		}
+		return properties
+	}
+	root := schema{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "go-rule-engine rules document",
+		"oneOf":   []schema{{"$ref": "#/$defs/rules"}, {"$ref": "#/$defs/rulesList"}},
+		"$defs": schema{
+			"rule": rule,
+			"rules": schema{
+				"type":                 "object",
+				"properties":           rulesProperties(true),
+				"required":             []string{"version", "kind", "rules"},
+				"additionalProperties": false,
+			},
+			"nestedRules": schema{
+				"type":                 "object",
+				"properties":           rulesProperties(false),
+				"required":             []string{"rules"},
+				"additionalProperties": false,
+			},
+			"rulesList": schema{
+				"type": "object",
+				"properties": schema{
+					"version":   schema{"const": DocumentVersion},
+					"kind":      schema{"const": kindRulesList},
+					"name":      schemaString,
+					"msg":       schemaString,
+					"rulesList": schema{"type": "array", "items": schema{"$ref": "#/$defs/nestedRules"}},
+				},
+				"required":             []string{"version", "kind", "rulesList"},
+				"additionalProperties": false,
+			},
+		},
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(EmptyStr, "  ")
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,253 @@
+package ruler
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema_Operators(t *testing.T) {
+	// 每个算符都要有val的约束，新增算符时须同步operatorValSchemas
+	assert.Equal(t, len(ValidAtomOperatorsDisplay), len(operatorValSchemas))
+	for _, op := range ValidAtomOperatorsDisplay {
+		_, ok := operatorValSchemas[op]
+		assert.True(t, ok, "operator %q has no val schema", op)
+	}
+	for alias, op := range operatorAliases {
+		assert.Contains(t, ValidAtomOperatorsDisplay, op, "alias %q", alias)
+	}
+
+	data, err := JSONSchema()
+	assert.Nil(t, err)
+	var root struct {
+		Defs map[string]struct {
+			Properties map[string]struct {
+				Enum []string `json:"enum"`
+			} `json:"properties"`
+			AllOf []interface{} `json:"allOf"`
+		} `json:"$defs"`
+	}
+	assert.Nil(t, json.Unmarshal(data, &root))
+	rule := root.Defs["rule"]
+	assert.Equal(t, len(ValidAtomOperatorsDisplay)+len(operatorAliases), len(rule.Properties["op"].Enum))
+	assert.Equal(t, len(ValidAtomOperatorsDisplay), len(rule.AllOf))
+	for _, op := range append(append([]string{}, ValidAtomOperatorsDisplay...), "eq", "@", "<<", "0") {
+		assert.Contains(t, rule.Properties["op"].Enum, op)
+	}
+}
+
+func TestJSONSchema_Published(t *testing.T) {
+	data, err := JSONSchema()
+	assert.Nil(t, err)
+	published, err := os.ReadFile("rules.schema.json")
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), string(published), "rules.schema.json is out of date, regenerate it with JSONSchema()")
+}
+
+func TestJSONSchema_Documents(t *testing.T) {
+	data, err := JSONSchema()
+	assert.Nil(t, err)
+	var root map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &root))
+
+	// schema接受的文档LoadRules、LoadRulesList也须接受
+	cases := map[string]bool{
+		`{"version": 1, "kind": "rules", "logic": "1 and 2", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1}, {"op": "in", "key": "B", "val": ["x", 2, true], "id": 2, "severity": "warning"}]}`: true,
+		`{"version": 1, "kind": "rules", "rules": []}`:                                                                                          true,
+		`{"version": 1, "kind": "rules", "rules": [{"op": "@", "key": "A", "val": "1, 2", "id": 1}]}`:                                           true,
+		`{"version": 1, "kind": "rules", "rules": [{"op": "=", "key": "A", "val": null, "id": 1}]}`:                                             true,
+		`{"version": 1, "kind": "rulesList", "rulesList": [{"name": "a", "rules": [{"op": "between", "key": "S", "val": "[1, 2]", "id": 1}]}]}`: true,
+		`{"version": 1, "kind": "rules", "rules": [{"op": "in", "key": "A", "val": [1, null], "id": 1}]}`:                                       false,
+		`{"version": 1, "kind": "rules", "rules": [{"op": "~", "key": "A", "val": 1, "id": 1}]}`:                                                false,
+		`{"version": 1, "kind": "rules", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1, "note": 1}]}`:                                     false,
+		`{"version": 1, "kind": "rules", "rules": [{"op": "=", "key": "A", "val": 1, "id": 1, "severity": "fatal"}]}`:                           false,
+		`{"version": 1, "kind": "rules", "rules": [{"op": "between", "key": "A", "val": 3, "id": 1}]}`:                                          false,
+		`{"version": 1, "kind": "rules"}`:                                                 false,
+		`{"kind": "rules", "rules": []}`:                                                  false,
+		`{"version": 1, "kind": "rulesList", "rules": []}`:                                false,
+		`{"version": 1, "kind": "rulesList", "rulesList": [{"version": 1, "rules": []}]}`: false,
+	}
+	for doc, valid := range cases {
+		var v interface{}
+		assert.Nil(t, json.Unmarshal([]byte(doc), &v))
+		assert.Equal(t, valid, matchSchema(root, root, v), "schema: %s", doc)
+		if !valid {
+			continue
+		}
+		if strings.Contains(doc, `"rulesList": [`) {
+			_, err = LoadRulesList([]byte(doc))
+		} else {
+			_, err = LoadRules([]byte(doc))
+		}
+		assert.Nil(t, err, "load: %s", doc)
+	}
+	_, err = LoadRules([]byte(`{"version": 1, "kind": "rules", "rules": [{"op": "in", "key": "A", "val": [1, null], "id": 1}]}`))
+	assert.NotNil(t, err)
+}
+
+// matchSchema 按rules.schema.json用到的关键字校验v，只用于测试
+func matchSchema(root, s map[string]interface{}, v interface{}) bool {
+	if ref, ok := s["$ref"].(string); ok {
+		target := root
+		for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			target = target[name].(map[string]interface{})
+		}
+		return matchSchema(root, target, v)
+	}
+	if types, ok := s["type"]; ok {
+		var names []interface{}
+		if list, ok := types.([]interface{}); ok {
+			names = list
+		} else {
+			names = []interface{}{types}
+		}
+		matched := false
+		for _, name := range names {
+			matched = matched || matchSchemaType(name.(string), v)
+		}
+		if !matched {
+			return false
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, v) {
+		return false
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, o := range enum {
+			found = found || reflect.DeepEqual(o, v)
+		}
+		if !found {
+			return false
+		}
+	}
+	if f, ok := v.(float64); ok {
+		if min, ok := s["minimum"].(float64); ok && f < min {
+			return false
+		}
+		if max, ok := s["maximum"].(float64); ok && f > max {
+			return false
+		}
+	}
+	if str, ok := v.(string); ok {
+		if pattern, ok := s["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(str) {
+			return false
+		}
+	}
+	if list, ok := v.([]interface{}); ok {
+		if min, ok := s["minItems"].(float64); ok && float64(len(list)) < min {
+			return false
+		}
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for _, o := range list {
+				if !matchSchema(root, items, o) {
+					return false
+				}
+			}
+		}
+	}
+	if o, ok := v.(map[string]interface{}); ok {
+		properties, _ := s["properties"].(map[string]interface{})
+		for _, name := range toStrings(s["required"]) {
+			if _, ok := o[name]; !ok {
+				return false
+			}
+		}
+		for name, value := range o {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				if !matchSchema(root, property, value) {
+					return false
+				}
+			} else if additional, ok := s["additionalProperties"]; ok {
+				if b, ok := additional.(bool); ok && !b {
+					return false
+				}
+				if additional, ok := additional.(map[string]interface{}); ok && !matchSchema(root, additional, value) {
+					return false
+				}
+			}
+		}
+	}
+	if cond, ok := s["if"].(map[string]interface{}); ok && matchSchema(root, cond, v) {
+		if then, ok := s["then"].(map[string]interface{}); ok && !matchSchema(root, then, v) {
+			return false
+		}
+	}
+	for _, sub := range toSchemas(s["allOf"]) {
+		if !matchSchema(root, sub, v) {
+			return false
+		}
+	}
+	if subs := toSchemas(s["anyOf"]); len(subs) > 0 {
+		matched := false
+		for _, sub := range subs {
+			matched = matched || matchSchema(root, sub, v)
+		}
+		if !matched {
+			return false
+		}
+	}
+	if subs := toSchemas(s["oneOf"]); len(subs) > 0 {
+		count := 0
+		for _, sub := range subs {
+			if matchSchema(root, sub, v) {
+				count++
+			}
+		}
+		if count != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func matchSchemaType(name string, v interface{}) bool {
+	switch name {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	default:
+		return false
+	}
+}
+
+func toStrings(v interface{}) []string {
+	list, _ := v.([]interface{})
+	strs := make([]string, 0, len(list))
+	for _, o := range list {
+		strs = append(strs, o.(string))
+	}
+	return strs
+}
+
+func toSchemas(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	schemas := make([]map[string]interface{}, 0, len(list))
+	for _, o := range list {
+		schemas = append(schemas, o.(map[string]interface{}))
+	}
+	return schemas
+}
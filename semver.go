@@ -0,0 +1,367 @@
+package ruler
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/**
+  语义化版本（semver 2.0）的比较：
+  1. 版本号可带前缀v，缺省的次版本号、修订号视为0：2.10 即 2.10.0
+  2. 预发布版本低于正式版本：1.0.0-alpha < 1.0.0-alpha.1 < 1.0.0-beta < 1.0.0，构建元数据(+xxx)不参与比较
+  3. 约束语法：空格或逗号分隔的条件取交集，||分隔的条件组取并集
+     ^1.2.3 即 >=1.2.3 <2.0.0，~1.2.3 即 >=1.2.3 <1.3.0，1.2.x 或 1.2 即 >=1.2.0 <1.3.0
+*/
+
+// version 语义化版本号
+type version struct {
+	nums [3]uint64
+	pre  []string
+}
+
+// versionComparator 单个比较条件，op为=、!=、>、>=、<、<=
+type versionComparator struct {
+	op string
+	v  version
+}
+
+// versionConstraint 条件组的并集，每组内条件取交集
+type versionConstraint [][]versionComparator
+
+// patternPrerelease 预发布版本的标识符
+var patternPrerelease = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// patternVersionOp 去掉算符与版本号之间的空白
+var patternVersionOp = regexp.MustCompile(`(>=|<=|!=|>|<|=|\^|~)\s+`)
+
+// parseVersion 解析版本号，缺省的部分视为0，不能含通配符
+func parseVersion(s string) (version, error) {
+	v, n, err := parsePartialVersion(s)
+	if err != nil {
+		return version{}, err
+	}
+	core := s
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core = s[:i]
+	}
+	if n == 0 || strings.ContainsAny(core, "xX*") {
+		return version{}, fmt.Errorf("invalid version %q", s)
+	}
+	return v, nil
+}
+
+// parsePartialVersion 解析可能不完整或含通配符(x、X、*)的版本号，n为给出的数字部分个数
+func parsePartialVersion(s string) (v version, n int, err error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	core, pre, hasPre := strings.Cut(s, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return version{}, 0, fmt.Errorf("invalid version %q", s)
+	}
+	wildcard := false
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" || part == EmptyStr && i == 0 && len(parts) == 1 {
+			wildcard = true
+			continue
+		}
+		num, err := strconv.ParseUint(part, 10, 64)
+		if wildcard || err != nil {
+			return version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		v.nums[i] = num
+		n++
+	}
+	if hasPre {
+		if n < 3 {
+			return version{}, 0, fmt.Errorf("invalid version %q: pre-release needs major.minor.patch", s)
+		}
+		v.pre = strings.Split(pre, ".")
+		for _, id := range v.pre {
+			if !patternPrerelease.MatchString(id) {
+				return version{}, 0, fmt.Errorf("invalid version %q: bad pre-release %q", s, id)
+			}
+		}
+	}
+	return v, n, nil
+}
+
+// compare 按semver规则比较版本号
+func (v version) compare(o version) int {
+	for i := range v.nums {
+		if v.nums[i] != o.nums[i] {
+			if v.nums[i] < o.nums[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	// 正式版本高于预发布版本
+	switch {
+	case len(v.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(o.pre); i++ {
+		if c := comparePrerelease(v.pre[i], o.pre[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.pre) < len(o.pre):
+		return -1
+	case len(v.pre) > len(o.pre):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePrerelease 数字标识符按数值比较且低于非数字标识符，非数字标识符按ASCII比较
+func comparePrerelease(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if na == nb {
+			return 0
+		}
+		if na < nb {
+			return -1
+		}
+		return 1
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// bump 第n个数字部分加一，其后清零，取最低的预发布版本作为开区间上界
+func (v version) bump(n int) version {
+	next := version{pre: []string{"0"}}
+	copy(next.nums[:], v.nums[:n])
+	next.nums[n-1]++
+	return next
+}
+
+func (c versionComparator) check(v version) bool {
+	cmp := v.compare(c.v)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return false
+	}
+}
+
+// check 满足任一条件组即可
+func (c versionConstraint) check(v version) bool {
+	for _, group := range c {
+		matched := true
+		for _, comparator := range group {
+			if !comparator.check(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVersionConstraint 解析版本约束，如 "^2.1"、"~1.4"、">=1.2 <2"、"1.x || >=3.1.0-beta"
+func parseVersionConstraint(s string) (versionConstraint, error) {
+	var constraint versionConstraint
+	for _, groupStr := range strings.Split(s, "||") {
+		groupStr = patternVersionOp.ReplaceAllString(strings.TrimSpace(groupStr), "$1")
+		group := []versionComparator{}
+		tokens := strings.FieldsFunc(groupStr, func(c rune) bool {
+			return c == ' ' || c == ',' || c == '\t'
+		})
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("constraint %q: empty condition", s)
+		}
+		for _, token := range tokens {
+			comparators, err := parseVersionComparator(token)
+			if err != nil {
+				return nil, fmt.Errorf("constraint %q: %s", s, err.Error())
+			}
+			group = append(group, comparators...)
+		}
+		constraint = append(constraint, group)
+	}
+	return constraint, nil
+}
+
+// parseVersionComparator 把单个条件展开为基本比较条件
+func parseVersionComparator(token string) ([]versionComparator, error) {
+	op := EmptyStr
+	for _, prefix := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(token, prefix) {
+			op = prefix
+			break
+		}
+	}
+	v, n, err := parsePartialVersion(token[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		// 通配符：>、<、!=不能与之搭配，其余条件匹配任意版本
+		if op == ">" || op == "<" || op == "!=" {
+			return nil, fmt.Errorf("%q matches no version", token)
+		}
+		return nil, nil
+	}
+	lower := versionComparator{op: ">=", v: v}
+	switch op {
+	case EmptyStr, "=":
+		if n == 3 {
+			return []versionComparator{{op: "=", v: v}}, nil
+		}
+		return []versionComparator{lower, {op: "<", v: v.bump(n)}}, nil
+	case "!=":
+		if n < 3 {
+			return nil, fmt.Errorf("%q needs a full version", token)
+		}
+		return []versionComparator{{op: "!=", v: v}}, nil
+	case ">":
+		if n == 3 {
+			return []versionComparator{{op: ">", v: v}}, nil
+		}
+		return []versionComparator{{op: ">=", v: v.bump(n)}}, nil
+	case ">=":
+		return []versionComparator{lower}, nil
+	case "<":
+		if n < 3 {
+			v.pre = []string{"0"}
+		}
+		return []versionComparator{{op: "<", v: v}}, nil
+	case "<=":
+		if n == 3 {
+			return []versionComparator{{op: "<=", v: v}}, nil
+		}
+		return []versionComparator{{op: "<", v: v.bump(n)}}, nil
+	case "~":
+		if n == 1 {
+			return []versionComparator{lower, {op: "<", v: v.bump(1)}}, nil
+		}
+		return []versionComparator{lower, {op: "<", v: v.bump(2)}}, nil
+	default:
+		// ^：不改变左起第一个非零部分
+		switch {
+		case v.nums[0] != 0 || n == 1:
+			return []versionComparator{lower, {op: "<", v: v.bump(1)}}, nil
+		case v.nums[1] != 0 || n == 2:
+			return []versionComparator{lower, {op: "<", v: v.bump(2)}}, nil
+		default:
+			return []versionComparator{lower, {op: "<", v: v.bump(3)}}, nil
+		}
+	}
+}
+
+// parseVersionRange 解析版本区间，如 "[1.2.0, 2.0.0)"，缺省的端点表示不限
+func parseVersionRange(s string) (versionConstraint, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || (s[0] != '[' && s[0] != '(') || (s[len(s)-1] != ']' && s[len(s)-1] != ')') {
+		return nil, fmt.Errorf("version range %q: expect [a, b], (a, b), [a, b) or (a, b]", s)
+	}
+	parts := strings.Split(s[1:len(s)-1], ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("version range %q should have exactly two bounds", s)
+	}
+	group := []versionComparator{}
+	for i, part := range parts {
+		if strings.TrimSpace(part) == EmptyStr {
+			continue
+		}
+		v, err := parseVersion(part)
+		if err != nil {
+			return nil, fmt.Errorf("version range %q: %s", s, err.Error())
+		}
+		op := ">"
+		if i == 1 {
+			op = "<"
+		}
+		if i == 0 && s[0] == '[' || i == 1 && s[len(s)-1] == ']' {
+			op += "="
+		}
+		group = append(group, versionComparator{op: op, v: v})
+	}
+	if len(group) == 0 {
+		return nil, fmt.Errorf("version range %q has no bound", s)
+	}
+	if len(group) == 2 && group[0].v.compare(group[1].v) > 0 {
+		return nil, fmt.Errorf("version range %q: lower bound is greater than upper bound", s)
+	}
+	return versionConstraint{group}, nil
+}
+
+// compileVersion 把版本算符的值统一预处理为版本约束
+func compileVersion(op string, val interface{}) (versionConstraint, error) {
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("value should be a version string, got %T", val)
+	}
+	switch op {
+	case "semver":
+		return parseVersionConstraint(str)
+	case "verbetween":
+		return parseVersionRange(str)
+	}
+	v, err := parseVersion(str)
+	if err != nil {
+		return nil, err
+	}
+	return versionConstraint{{{op: strings.TrimPrefix(op, "ver"), v: v}}}, nil
+}
+
+// fitVersion 版本相关算符的匹配，实际值不是合法版本号时不满足
+func (r *Rule) fitVersion(v interface{}) (result bool, handled bool) {
+	switch r.Op {
+	case "ver=", "ver>", "ver>=", "ver<", "ver<=", "verbetween", "semver":
+	default:
+		return false, false
+	}
+	constraint, ok := r.compiledVal().(versionConstraint)
+	if !ok {
+		return false, true
+	}
+	var str string
+	switch t := v.(type) {
+	case string:
+		str = t
+	case json.Number:
+		str = t.String()
+	default:
+		return false, true
+	}
+	actual, err := parseVersion(str)
+	if err != nil {
+		return false, true
+	}
+	return constraint.check(actual), true
+}
@@ -0,0 +1,99 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion_Compare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "2.9.0", "2.10", "v2.10.3", "2.10.3+exp.x"}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, err := parseVersion(ordered[i])
+		assert.Nil(t, err, ordered[i])
+		b, err := parseVersion(ordered[i+1])
+		assert.Nil(t, err, ordered[i+1])
+		expect := -1
+		if i+1 == len(ordered)-1 {
+			// 构建元数据不参与比较
+			expect = 0
+		}
+		assert.Equal(t, expect, a.compare(b), "%s %s", ordered[i], ordered[i+1])
+	}
+
+	for _, s := range []string{"", "x", "1.2.3.4", "1.a", "1.2-beta", "1.2.3-", "1.2.3-be_ta", "-1"} {
+		_, err := parseVersion(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+	cases := map[string]map[string]bool{
+		"^2.1":                {"2.1.0": true, "2.9.9": true, "3.0.0": false, "3.0.0-beta": false, "2.0.9": false},
+		"^0.2.3":              {"0.2.3": true, "0.2.9": true, "0.3.0": false},
+		"^0.0.3":              {"0.0.3": true, "0.0.4": false},
+		"~1.4":                {"1.4.0": true, "1.4.9": true, "1.5.0": false},
+		"~1":                  {"1.9.0": true, "2.0.0": false},
+		">=1.2 <2":            {"1.2.0": true, "1.99.0": true, "2.0.0-rc.1": false, "2.0.0": false, "1.1.9": false},
+		">= 1.2, < 2":         {"1.5.0": true},
+		"1.2.x":               {"1.2.7": true, "1.3.0": false},
+		"1.x || >=3.1.0-beta": {"1.0.1": true, "2.0.0": false, "3.1.0-beta": true, "3.1.0-alpha": false, "4.0.0": true},
+		"*":                   {"0.0.1": true},
+		"!=1.2.3":             {"1.2.3": false, "1.2.4": true},
+		">1.2":                {"1.2.9": false, "1.3.0": true},
+		"<=1.2":               {"1.2.9": true, "1.3.0": false},
+		"=v2.0.0":             {"2.0.0": true, "2.0.1": false},
+	}
+	for s, values := range cases {
+		constraint, err := parseVersionConstraint(s)
+		if !assert.Nil(t, err, s) {
+			continue
+		}
+		for v, expect := range values {
+			actual, _ := parseVersion(v)
+			assert.Equal(t, expect, constraint.check(actual), "%s %s", s, v)
+		}
+	}
+
+	for _, s := range []string{"", "1.2 ||", ">x", "!=1.2", "^1.a", "1.2 - 2.0"} {
+		_, err := parseVersionConstraint(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestRules_FitVersion(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "ver>=", "key": "AppVersion", "val": "2.10.3", "id": 1, "msg": "too old"},
+	{"op": "ver<", "key": "AppVersion", "val": "3", "id": 2, "msg": "too new"},
+	{"op": "verbetween", "key": "OSVersion", "val": "[14.0, 17.2)", "id": 3, "msg": "os"},
+	{"op": "semver", "key": "SDKVersion", "val": "^1.4 || ~2.0.1", "id": 4, "msg": "sdk"},
+	{"op": "ver=", "key": "Channel", "val": "1.0.0-beta", "id": 5, "msg": "channel"}
+	]`)
+	rs, err := NewRulesWithJSONAndLogic(jsonRules, "")
+	assert.Nil(t, err)
+	type Client struct {
+		AppVersion string
+		OSVersion  string
+		SDKVersion string
+		Channel    string
+	}
+	fit, msg := rs.Fit(&Client{AppVersion: "2.10.3", OSVersion: "16.4.1", SDKVersion: "2.0.5", Channel: "v1.0.0-beta+exp"})
+	assert.True(t, fit, msg)
+	fit, msg = rs.Fit(&Client{AppVersion: "2.9.0", OSVersion: "17.2", SDKVersion: "2.1.0", Channel: "1.0.0"})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "too old", 3: "os", 4: "sdk", 5: "channel"}, msg)
+	fit, _ = rs.Fit(&Client{AppVersion: "unknown", OSVersion: "16", SDKVersion: "1.9.0", Channel: "1.0.0-beta"})
+	assert.False(t, fit)
+
+	for _, val := range []string{`"2.x"`, `3`, `"[2.0, 1.0]"`} {
+		op := "ver>"
+		if val == `"[2.0, 1.0]"` {
+			op = "verbetween"
+		}
+		_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "`+op+`", "key": "A", "val": `+val+`, "id": 1}]`), "")
+		assert.NotNil(t, err, val)
+	}
+
+	_, err = Compile[typedStudent]([]byte(`[{"op": "semver", "key": "Grade", "val": "^1", "id": 1}]`), "")
+	assert.NotNil(t, err)
+}
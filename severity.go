@@ -0,0 +1,66 @@
+package ruler
+
+/**
+  子规则的级别和警告模式：
+  1. severity为error（默认）、warning或info
+  2. Fit等方法不区分级别，任何子规则都可能导致fit为false
+  3. FitWarn时warning、info级别子规则不参与计算：从逻辑表达式中去掉，逻辑表达式中只有这些子规则时fit为true
+     不满足的warning、info级别子规则，其负提示另外返回
+*/
+
+// FitWarn Rules以警告模式匹配结构体，warnings为不满足的warning、info级别子规则的负提示
+func (rs *Rules) FitWarn(o interface{}) (bool, map[int]string, map[int]string) {
+	return rs.fitWarn(rs.structGetter(rs.Adapter, o), EmptyStr)
+}
+
+// FitWithMapWarn Rules以警告模式匹配map
+func (rs *Rules) FitWithMapWarn(o map[string]interface{}) (bool, map[int]string, map[int]string) {
+	return rs.fitWarn(mapGetter(o), EmptyStr)
+}
+
+// FitWarnLocale Rules以警告模式匹配结构体，提示使用指定语言
+func (rs *Rules) FitWarnLocale(o interface{}, locale string) (bool, map[int]string, map[int]string) {
+	return rs.fitWarn(rs.structGetter(rs.Adapter, o), locale)
+}
+
+// FitWithMapWarnLocale Rules以警告模式匹配map，提示使用指定语言
+func (rs *Rules) FitWithMapWarnLocale(o map[string]interface{}, locale string) (bool, map[int]string, map[int]string) {
+	return rs.fitWarn(mapGetter(o), locale)
+}
+
+func (rs *Rules) fitWarn(get valueGetter, locale string) (bool, map[int]string, map[int]string) {
+	warnings := make(map[int]string)
+	answer, tips, _, _ := rs.judge(get, false, locale, warnings, nil)
+	return answer == TruthTrue, tips, warnings
+}
+
+// warn 警告模式下不满足的warning、info级别子规则的负提示记入warnings，返回去掉这些子规则后的逻辑表达式
+func (rs *Rules) warn(results map[int]Truth, values map[int]interface{}, locale string, warnings map[int]string) string {
+	for _, rule := range rs.Rules {
+		if !rule.blocking() && results[rule.ID] == TruthFalse {
+			warnings[rule.ID] = rs.tipIn(rule, values[rule.ID], locale, false)
+		}
+	}
+	return rs.blockingLogic()
+}
+
+// blockingLogic 去掉warning、info级别子规则后的逻辑表达式，如 1 or 2、1 and 2 中1是warning时都为2
+// 逻辑表达式中只有warning、info级别子规则时为空字符串
+func (rs *Rules) blockingLogic() string {
+	neutral := make(map[int]bool)
+	for _, rule := range rs.Rules {
+		if !rule.blocking() {
+			neutral[rule.ID] = true
+		}
+	}
+	head := logicToTree(rs.Logic)
+	if head == nil || len(neutral) == 0 {
+		return rs.Logic
+	}
+	return head.pruneLogic(neutral)
+}
+
+// blocking 子规则不满足时是否影响fit
+func (r *Rule) blocking() bool {
+	return r.Severity == EmptyStr || r.Severity == SeverityError
+}
@@ -0,0 +1,165 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_PassMsg(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match", "passMsg": "Grade is {{.Actual}}", "passMsgs": {"zh": "{{.Actual}}年级"}},
+	{"op": "=", "key": "Sex", "val": "male", "id": 2, "msg": "not male", "passMsg": "male"},
+	{"op": ">=", "key": "Score.Math", "val": 90, "id": 3, "msg": "Math not so well"}
+	]`), "1 and not 2 and 3")
+	assert.Nil(t, err)
+
+	o := map[string]interface{}{"Grade": 3, "Sex": "female", "Score": map[string]interface{}{"Math": 95}}
+	fit, tips := rs.FitWithMap(o)
+	assert.True(t, fit)
+	// 按子规则自身的结果选择正负提示，满足但没有passMsg的子规则沿用msg
+	assert.Equal(t, map[int]string{1: "Grade is 3", 2: "not male", 3: "Math not so well"}, tips)
+	fit, tips = rs.FitWithMapLocale(o, "zh-CN")
+	assert.True(t, fit)
+	assert.Equal(t, "3年级", tips[1])
+
+	o["Grade"] = 4
+	fit, tips = rs.FitWithMap(o)
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "Grade not match"}, tips)
+
+	// not下不满足的子规则导致fit为true，使用负提示，与FitResult一致
+	rs, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match", "passMsg": "Grade is {{.Actual}}, matched"}]`), "not 1")
+	assert.Nil(t, err)
+	fit, tips = rs.FitWithMap(map[string]interface{}{"Grade": 4})
+	assert.True(t, fit)
+	assert.Equal(t, map[int]string{1: "Grade not match"}, tips)
+	assert.Equal(t, "Grade not match", rs.FitWithMapResult(map[string]interface{}{"Grade": 4}).Outcomes[0].Msg)
+	fit, tips = rs.FitWithMap(map[string]interface{}{"Grade": 3})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "Grade is 3, matched"}, tips)
+}
+
+func TestRules_FitWarn(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match"},
+	{"op": "nempty", "key": "Email", "id": 2, "msg": "email is recommended", "severity": "warning"},
+	{"op": ">=", "key": "Score.Math", "val": 90, "id": 3, "msg": "Math score {{.Actual}} is low", "severity": "info"}
+	]`), "")
+	assert.Nil(t, err)
+
+	o := map[string]interface{}{"Grade": 3, "Score": map[string]interface{}{"Math": 85}}
+	fit, tips := rs.FitWithMap(o)
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "email is recommended", 3: "Math score 85 is low"}, tips)
+
+	fit, tips, warnings := rs.FitWithMapWarn(o)
+	assert.True(t, fit)
+	assert.Equal(t, map[int]string{1: "Grade not match"}, tips)
+	assert.Equal(t, map[int]string{2: "email is recommended", 3: "Math score 85 is low"}, warnings)
+
+	type Student struct {
+		Grade int
+	}
+	fit, tips, warnings = rs.FitWarn(&Student{Grade: 2})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "Grade not match"}, tips)
+	assert.Equal(t, map[int]string{2: "email is recommended", 3: "Math score  is low"}, warnings)
+
+	// or中的warning子规则不能替代error级别的分支
+	rs, err = NewRulesWithDSL(`Grade = 3 @msg("Grade not match") and (Email nempty @severity("warning") or Phone nempty @msg("phone is required"))`)
+	assert.Nil(t, err)
+	fit, tips, warnings = rs.FitWithMapWarn(map[string]interface{}{"Grade": 3})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{3: "phone is required"}, tips)
+	assert.Equal(t, map[int]string{2: ""}, warnings)
+	fit, tips, warnings = rs.FitWithMapWarn(map[string]interface{}{"Grade": 3, "Phone": "123"})
+	assert.True(t, fit)
+	assert.Equal(t, map[int]string{1: "Grade not match", 3: "phone is required"}, tips)
+	assert.Equal(t, map[int]string{2: ""}, warnings)
+}
+
+func TestRules_FitWarnUnderOr(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "A", "val": 1, "id": 1, "msg": "A bad", "severity": "warning"},
+	{"op": "=", "key": "B", "val": 1, "id": 2, "msg": "B bad"}
+	]`), "1 or 2")
+	assert.Nil(t, err)
+	o := map[string]interface{}{"A": 0, "B": 0}
+	fit, _ := rs.FitWithMap(o)
+	assert.False(t, fit)
+	fit, tips, warnings := rs.FitWithMapWarn(o)
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "B bad"}, tips)
+	assert.Equal(t, map[int]string{1: "A bad"}, warnings)
+
+	// warning子规则满足时也不能让fit为true
+	fit, tips, warnings = rs.FitWithMapWarn(map[string]interface{}{"A": 1, "B": 0})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{2: "B bad"}, tips)
+	assert.Empty(t, warnings)
+
+	// 逻辑表达式中只有warning、info级别子规则时fit为true
+	rs.Rules[1].Severity = SeverityInfo
+	fit, tips, warnings = rs.FitWithMapWarn(o)
+	assert.True(t, fit)
+	assert.Empty(t, tips)
+	assert.Equal(t, map[int]string{1: "A bad", 2: "B bad"}, warnings)
+}
+
+func TestRule_Severity(t *testing.T) {
+	_, err := NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": 1, "id": 1, "severity": "fatal"}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `rule 1: unknown severity "fatal"`, err.Error())
+	}
+	_, err = NewRulesWithJSONAndLogic([]byte(`[{"op": "=", "key": "A", "val": 1, "id": 1, "passMsg": "{{.Actual"}]`), "")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "rule 1: invalid passMsg template")
+	}
+
+	src := `Grade = 3 @msg("Grade not match") @passMsg("Grade matched") @passMsgs({"zh":"年级符合"}) @severity("warning")`
+	rs, err := NewRulesWithDSL(src)
+	assert.Nil(t, err)
+	assert.Equal(t, SeverityWarning, rs.Rules[0].Severity)
+	dsl, err := rs.DSL()
+	assert.Nil(t, err)
+	assert.Equal(t, src, dsl)
+
+	data, err := rs.MarshalJSON()
+	assert.Nil(t, err)
+	loaded, err := LoadRules(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "Grade matched", loaded.Rules[0].PassMsg)
+	assert.Equal(t, map[string]string{"zh": "年级符合"}, loaded.Rules[0].PassMsgs)
+	assert.Equal(t, SeverityWarning, loaded.Rules[0].Severity)
+}
+
+func TestRules_FitWarnUnderNot(t *testing.T) {
+	rules := []*Rule{
+		{Op: "=", Key: "Grade", Val: 3, ID: 1},
+		{Op: "=", Key: "Sex", Val: "male", ID: 2, Msg: "not male", PassMsg: "is male", Severity: SeverityWarning},
+	}
+	rs, err := NewRulesWithArrayAndLogic(rules, "1 and not 2")
+	assert.Nil(t, err)
+
+	// not下的warning子规则同样不参与计算，只有自身不满足时记入warnings
+	female := map[string]interface{}{"Grade": 3, "Sex": "female"}
+	fit, _ := rs.FitWithMap(female)
+	assert.True(t, fit)
+	var tips, warnings map[int]string
+	fit, tips, warnings = rs.FitWithMapWarn(female)
+	assert.True(t, fit)
+	assert.Equal(t, map[int]string{1: ""}, tips)
+	assert.Equal(t, map[int]string{2: "not male"}, warnings)
+
+	male := map[string]interface{}{"Grade": 3, "Sex": "male"}
+	fit, _ = rs.FitWithMap(male)
+	assert.False(t, fit)
+	fit, tips, warnings = rs.FitWithMapWarn(male)
+	assert.True(t, fit)
+	assert.Equal(t, map[int]string{1: ""}, tips)
+	assert.Empty(t, warnings)
+
+	fit, _, _ = rs.FitWithMapWarn(map[string]interface{}{"Grade": 2, "Sex": "female"})
+	assert.False(t, fit)
+}
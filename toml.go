@@ -0,0 +1,269 @@
+package ruler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+/**
+  toml格式的规则文档，字段与json文档一致：
+  version = 1
+  kind = "rules"
+  logic = "1 and 2"
+
+  [[rules]]  # 三年级
+  op = "="
+  key = "Grade"
+  val = 3
+  id = 1
+  RulesList的文档用[[rulesList]]和[[rulesList.rules]]；校验错误按表头和键名定位行列号
+*/
+
+// LoadRulesTOML 读取并校验toml格式的Rules文档
+func LoadRulesTOML(data []byte) (*Rules, error) {
+	var doc rulesDocument
+	if err := decodeTOML(data, &doc); err != nil {
+		return nil, err
+	}
+	rs, err := doc.load()
+	if err != nil {
+		return nil, tomlError(data, err)
+	}
+	return rs, nil
+}
+
+// LoadRulesListTOML 读取并校验toml格式的RulesList文档
+func LoadRulesListTOML(data []byte) (*RulesList, error) {
+	var doc rulesListDocument
+	if err := decodeTOML(data, &doc); err != nil {
+		return nil, err
+	}
+	rst, err := doc.load()
+	if err != nil {
+		return nil, tomlError(data, err)
+	}
+	return rst, nil
+}
+
+// decodeTOML 严格解码，未知字段视为错误
+func decodeTOML(data []byte, doc interface{}) error {
+	md, err := toml.NewDecoder(bytes.NewReader(data)).Decode(doc)
+	if err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) && pe.Position.Line > 0 {
+			return fmt.Errorf("toml: line %d, column %d: invalid rules document: %s", pe.Position.Line, maxInt(pe.Position.Col, 1), pe.Message)
+		}
+		return fmt.Errorf("invalid rules document: %s", err.Error())
+	}
+	undecoded := make(map[string]bool)
+	for _, key := range md.Undecoded() {
+		undecoded[key.String()] = true
+	}
+	// 按出现顺序统计数组表头，得到未知字段所在的数组表下标
+	counts := make(map[string]int)
+	for _, key := range md.Keys() {
+		name := key.String()
+		if md.Type(key...) == "ArrayHash" && !undecoded[name] {
+			counts[name]++
+			for other := range counts {
+				if strings.HasPrefix(other, name+".") {
+					delete(counts, other)
+				}
+			}
+		}
+		if !undecoded[name] || isFreeFormKey(key) {
+			continue
+		}
+		err := fmt.Errorf("invalid rules document: unknown field %q", name)
+		lines := tomlLines(data)
+		if line := tomlKeyPosition(lines, md, key, counts); line >= 0 {
+			return tomlLineError(lines, line, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// tomlKeyPosition 按键的路径和数组表下标定位所在的行，未知的表头定位到表头所在的行
+func tomlKeyPosition(lines []string, md toml.MetaData, key toml.Key, counts map[string]int) int {
+	switch md.Type(key...) {
+	case "Hash":
+		return tomlTableLine(lines, "["+key.String()+"]")
+	case "ArrayHash":
+		return tomlTableLine(lines, "[["+key.String()+"]]")
+	}
+	var path []interface{}
+	for i, name := range key {
+		path = append(path, name)
+		if i < len(key)-1 && md.Type(key[:i+1]...) == "ArrayHash" {
+			path = append(path, counts[key[:i+1].String()]-1)
+		}
+	}
+	return tomlPosition(lines, path)
+}
+
+// tomlTableLine 第一个表头为header的行
+func tomlTableLine(lines []string, header string) int {
+	for i, line := range lines {
+		if h, ok := tomlHeader(line); ok && h == header {
+			return i
+		}
+	}
+	return -1
+}
+
+// isFreeFormKey val中的表可以有任意的键
+func isFreeFormKey(key toml.Key) bool {
+	for _, name := range key[:len(key)-1] {
+		if name == "val" {
+			return true
+		}
+	}
+	return false
+}
+
+// tomlError 校验错误加上出错位置的行列号
+func tomlError(data []byte, err error) error {
+	e, ok := err.(*documentError)
+	if !ok {
+		return err
+	}
+	lines := tomlLines(data)
+	line := tomlPosition(lines, e.path)
+	if line < 0 {
+		return err
+	}
+	return tomlLineError(lines, line, err)
+}
+
+func tomlLineError(lines []string, line int, err error) error {
+	column := len(lines[line]) - len(strings.TrimLeft(lines[line], " \t")) + 1
+	return fmt.Errorf("toml: line %d, column %d: %s", line+1, column, err.Error())
+}
+
+// tomlPosition 按数组表头和键名扫描路径所在的行（从0开始），找不到时返回-1
+// 如 ["rulesList", 1, "rules", 0] 是第2个[[rulesList]]之后的第1个[[rulesList.rules]]
+func tomlPosition(lines []string, path []interface{}) int {
+	// 在表头after之后、end之前查找，根表的after为-1
+	found, after, end, prefix := -1, -1, len(lines), EmptyStr
+	for i := 0; i < len(path); i++ {
+		name, ok := path[i].(string)
+		if !ok {
+			break
+		}
+		index, isTable := 0, false
+		if i+1 < len(path) {
+			index, isTable = path[i+1].(int)
+		}
+		if !isTable {
+			if line := tomlKeyLine(lines, after, end, name); line >= 0 {
+				found = line
+			}
+			break
+		}
+		header := prefix + name
+		line := tomlArrayTable(lines, after, end, header, index)
+		if line < 0 {
+			// 内联数组，定位到键名所在的行
+			if line = tomlKeyLine(lines, after, end, name); line >= 0 {
+				found = line
+			}
+			break
+		}
+		found, after, prefix = line, line, header+"."
+		for next := line + 1; next < end; next++ {
+			if h, ok := tomlHeader(lines[next]); ok && !strings.HasPrefix(strings.Trim(h, "[]"), prefix) {
+				end = next
+				break
+			}
+		}
+		i++
+	}
+	return found
+}
+
+// tomlArrayTable 第index个（从0开始）名为name的数组表头所在的行
+func tomlArrayTable(lines []string, after, end int, name string, index int) int {
+	count := 0
+	for i := after + 1; i < end; i++ {
+		if h, ok := tomlHeader(lines[i]); ok && h == "[["+name+"]]" {
+			if count == index {
+				return i
+			}
+			count++
+		}
+	}
+	return -1
+}
+
+// tomlKeyLine 表头after之后到下一个表头之前，键名所在的行
+func tomlKeyLine(lines []string, after, end int, name string) int {
+	for i := after + 1; i < end; i++ {
+		if _, ok := tomlHeader(lines[i]); ok {
+			return -1
+		}
+		key, _, ok := strings.Cut(lines[i], "=")
+		if ok && strings.Trim(strings.TrimSpace(key), `"'`) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// tomlLines 按行拆分，多行字符串和多行数组的后续行置空，避免被当作表头或键
+func tomlLines(data []byte) []string {
+	lines := strings.Split(string(data), "\n")
+	var quote string // 未结束的多行字符串的引号
+	var depth int    // 未闭合的数组、内联表层数
+	for i, line := range lines {
+		if quote != EmptyStr || depth > 0 {
+			lines[i] = EmptyStr
+		} else if _, ok := tomlHeader(line); ok {
+			continue
+		}
+		for j := 0; j < len(line); j++ {
+			if quote != EmptyStr {
+				if line[j] == '\\' && quote == `"""` {
+					j++
+				} else if strings.HasPrefix(line[j:], quote) {
+					j += len(quote) - 1
+					quote = EmptyStr
+				}
+				continue
+			}
+			switch c := line[j]; {
+			case c == '#':
+				j = len(line)
+			case strings.HasPrefix(line[j:], `"""`) || strings.HasPrefix(line[j:], "'''"):
+				quote = line[j : j+3]
+				j += 2
+			case c == '"' || c == '\'':
+				// 单行字符串，跳到结束的引号
+				for j++; j < len(line) && line[j] != c; j++ {
+					if c == '"' && line[j] == '\\' {
+						j++
+					}
+				}
+			case c == '[' || c == '{':
+				depth++
+			case c == ']' || c == '}':
+				depth--
+			}
+		}
+	}
+	return lines
+}
+
+// tomlHeader 表头去掉空白和注释，如 [[rulesList.rules]]、[owner]
+func tomlHeader(line string) (string, bool) {
+	line, _, _ = strings.Cut(line, "#")
+	line = strings.Join(strings.Fields(line), EmptyStr)
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return EmptyStr, false
+	}
+	return line, true
+}
@@ -0,0 +1,121 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const studentTOML = `# 好学生
+version = 1
+kind = "rules"
+name = "student"
+logic = "1 and (2 or 3)"
+
+[val]
+level = 3
+
+[[rules]] # 三年级
+op = "="
+key = "Grade"
+val = 3
+id = 1
+msg = "Grade not match"
+
+[[rules]]
+op = "in"
+key = "Sex"
+val = ["male", "female"]
+id = 2
+
+[[rules]]
+op = "between"
+key = "Score.Math"
+val = "[90, ]"
+id = 3
+`
+
+func TestLoadRulesTOML(t *testing.T) {
+	rs, err := LoadRulesTOML([]byte(studentTOML))
+	assert.Nil(t, err)
+	assert.Equal(t, "student", rs.Name)
+	assert.Equal(t, map[string]interface{}{"level": int64(3)}, rs.Val)
+	assert.Equal(t, 3, len(rs.Rules))
+	assert.Equal(t, "Grade not match", rs.Rules[0].Msg)
+	fit, _ := rs.FitWithMap(map[string]interface{}{"Grade": 3, "Score": map[string]interface{}{"Math": 92}})
+	assert.True(t, fit)
+	fit, _ = rs.FitWithMap(map[string]interface{}{"Grade": 3, "Sex": "unknown"})
+	assert.False(t, fit)
+}
+
+func TestLoadRulesTOML_Errors(t *testing.T) {
+	cases := map[string]string{
+		"version = 1\nkind = \"rules\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = 1\nid = 1\n\n[[rules]]\nop = \"between\"\nkey = \"A\"\nval = \"[3, 1]\"\nid = 2\n": "toml: line 10, column 1: rule 2: interval",
+		"version = 1\nkind = \"rules\"\nlogic = \"1 or 3\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = 1\nid = 1\n":                                                   "toml: line 3, column 1: invalid logic expression",
+		"version = 2\nkind = \"rules\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = 1\nid = 1\n":                                                                       "toml: line 1, column 1: invalid rules document: unsupported version 2",
+		"version = 1\nkind = \"rules\"\nrules = [\n  {op = \"=\", key = \"A\", val = 1, id = 1},\n  {op = \"between\", key = \"A\", val = \"[3, 1]\", id = 2},\n]\n":   "toml: line 3, column 1: rule 2:",
+		"version = 1\nkind = \"rules\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = 1\nid = 1\n  note = \"x\"\n":                                                       `toml: line 9, column 3: invalid rules document: unknown field "rules.note"`,
+		"version = 1\nkind = \"rules\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = 1\nid = \"x\"\n":                                                                   "toml: line 8",
+		"version = 1\nkind = \"rules\"\nrules = [\n": "toml: line",
+		// 同名的键出现在别处时，按所在的表定位
+		"version = 1\nkind = \"rules\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = { note = 1 }\nid = 1\n\n[[rules]]\nop = \"=\"\nkey = \"B\"\nval = 2\nid = 2\nnote = \"x\"\n": `toml: line 15, column 1: invalid rules document: unknown field "rules.note"`,
+		"note = \"top\"\nversion = 1\nkind = \"rules\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = 1\nid = 1\n":                                                                 `toml: line 1, column 1: invalid rules document: unknown field "note"`,
+		"version = 1\nkind = \"rules\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = 1\nid = 1\n\n[owner]\nname = \"x\"\n":                                                        `toml: line 10, column 1: invalid rules document: unknown field "owner"`,
+		// 多行数组、多行字符串中以[开头的行不是表头
+		"version = 1\nkind = \"rules\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = [\n  [1.0, 2.0],\n  [3.0, 4.0]\n]\nid = 1\nnote = \"x\"\n":                                                          `toml: line 12, column 1: invalid rules document: unknown field "rules.note"`,
+		"version = 1\nkind = \"rules\"\n\n[[rules]]\nop = \"=\"\nkey = \"A\"\nval = 1\nid = 1\nmsg = \"\"\"\n[[rules]]\n\"\"\"\n\n[[rules]]\nop = \"between\"\nkey = \"A\"\nval = \"[3, 1]\"\nid = 2\n": "toml: line 13, column 1: rule 2: interval",
+	}
+	for doc, expect := range cases {
+		_, err := LoadRulesTOML([]byte(doc))
+		if assert.NotNil(t, err, doc) {
+			assert.Contains(t, err.Error(), expect, doc)
+		}
+	}
+}
+
+func TestLoadRulesListTOML(t *testing.T) {
+	doc := `version = 1
+kind = "rulesList"
+name = "levels"
+
+[[rulesList]]
+name = "gold"
+logic = "1 and 2"
+val = "gold"
+
+  [[rulesList.rules]]
+  op = ">="
+  key = "Score"
+  val = 90
+  id = 1
+
+  [[rulesList.rules]]
+  op = "="
+  key = "Vip"
+  val = 1
+  id = 2
+
+[[rulesList]]
+
+  [[rulesList.rules]]
+  op = ">="
+  key = "Score"
+  val = 60
+  id = 1
+`
+	rst, err := LoadRulesListTOML([]byte(doc))
+	assert.Nil(t, err)
+	assert.Equal(t, "levels", rst.Name)
+	assert.Equal(t, "gold", rst.Fit(map[string]interface{}{"Score": 95, "Vip": 1}).Val)
+	assert.Same(t, rst.RulesList[1], rst.Fit(map[string]interface{}{"Score": 95}))
+
+	_, err = LoadRulesListTOML([]byte(doc[:len(doc)-len("  val = 60\n  id = 1\n")] + "  val = \"x\"\n  id = 1\n\n  [[rulesList.rules]]\n  op = \"between\"\n  key = \"Score\"\n  val = \"[9, 1]\"\n"))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "toml: line 30, column 3: rulesList[1]: rule 2:")
+	}
+	// 第2个Rules中的未知字段
+	_, err = LoadRulesListTOML([]byte(doc + "  note = \"x\"\n"))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `toml: line 29, column 3: invalid rules document: unknown field "rulesList.rules.note"`)
+	}
+}
@@ -14,33 +14,37 @@ import (
 
 /**
   利用树来计算规则引擎
-  输入：子规则ID和逻辑值map
-  输出：规则匹配结果，导致匹配false的子规则ID/导致true的IDs
+  输入：逻辑表达式，子规则ID和逻辑值map，值可以是Unknown
+  输出：规则匹配结果，导致匹配false的子规则ID/导致true的IDs/导致无法判定的IDs
 */
-func (rs *Rules) calculateExpressionByTree(values map[int]bool) (bool, []int, error) {
+func (rs *Rules) calculateExpressionByTree(logic string, values map[int]Truth) (Truth, []int, error) {
 	var ruleIDs []int
-	head := logicToTree(rs.Logic)
-	err := head.traverseTreeInPostOrderForCalculate(values)
+	head := logicToTree(logic)
+	err := head.traverseTreeInPostOrderForCalculateTri(values)
 	if err != nil {
-		return false, nil, err
+		return TruthFalse, nil, err
 	}
 	if !head.Computed {
-		return false, nil, errors.New("didn't count out yet")
+		return TruthFalse, nil, errors.New("didn't count out yet")
+	}
+	if head.Unknown {
+		// 无法判定，找出缺失的子规则
+		return TruthUnknown, head.traverseTreeToFindUnknownRule(ruleIDs), nil
 	}
 	if !head.Val {
 		// fail了需要找原因
 		ruleIDs, err = head.traverseTreeInLayerToFindFailRule(ruleIDs)
 		if err != nil {
-			return false, nil, err
+			return TruthFalse, nil, err
 		}
 	} else {
 		// true也返回相应的ruleIDs
 		ruleIDs, err = head.traverseTreeInLayerToFindSuccessRule(ruleIDs)
 		if err != nil {
-			return false, nil, err
+			return TruthFalse, nil, err
 		}
 	}
-	return head.Val, ruleIDs, nil
+	return truthOf(head.Val), ruleIDs, nil
 }
 
 /**
@@ -64,13 +68,24 @@ func logicToTree(logic string) *Node {
   计算树所有节点值的核心方法
 */
 func (node *Node) traverseTreeInPostOrderForCalculate(values map[int]bool) error {
+	var tri = make(map[int]Truth, len(values))
+	for id, val := range values {
+		tri[id] = truthOf(val)
+	}
+	return node.traverseTreeInPostOrderForCalculateTri(tri)
+}
+
+/**
+  按Kleene三值逻辑计算树所有节点值
+*/
+func (node *Node) traverseTreeInPostOrderForCalculateTri(values map[int]Truth) error {
 	if node == nil {
 		return nil
 	}
 
 	children := node.Children
 	for _, child := range children {
-		err := child.traverseTreeInPostOrderForCalculate(values)
+		err := child.traverseTreeInPostOrderForCalculateTri(values)
 		if err != nil {
 			return err
 		}
@@ -82,8 +97,7 @@ func (node *Node) traverseTreeInPostOrderForCalculate(values map[int]bool) error
 			return err
 		}
 		if val, ok := values[ruleID]; ok {
-			node.Val = val
-			node.Computed = true
+			node.setTruth(val)
 		} else {
 			return fmt.Errorf("not exist rule_id: %d", ruleID)
 		}
@@ -91,25 +105,120 @@ func (node *Node) traverseTreeInPostOrderForCalculate(values map[int]bool) error
 	}
 	// calculate not-leaf node by children and their op
 	op := node.ChildrenOp
-	tmpVal := node.Children[0].Val
+	tmpVal := node.Children[0].truth()
+	var err error
 	if numOfOperandInLogic(op) == 1 {
-		node.Val = !tmpVal
-		node.Computed = true
+		tmpVal, err = computeOneInLogicTri(op, []Truth{tmpVal})
+		if err != nil {
+			return err
+		}
 	} else {
-		var err error
 		for _, child := range node.Children {
 			// because a = a and a, a = a or a, so can simply duplicated
-			tmpVal, err = computeOneInLogic(op, []bool{tmpVal, child.Val})
+			tmpVal, err = computeOneInLogicTri(op, []Truth{tmpVal, child.truth()})
 			if err != nil {
 				return err
 			}
 		}
-		node.Val = tmpVal
-		node.Computed = true
 	}
+	node.setTruth(tmpVal)
 	return nil
 }
 
+func (node *Node) truth() Truth {
+	if node.Unknown {
+		return TruthUnknown
+	}
+	return truthOf(node.Val)
+}
+
+func (node *Node) setTruth(t Truth) {
+	node.Val = t == TruthTrue
+	node.Unknown = t == TruthUnknown
+	node.Computed = true
+}
+
+/**
+  获取导致树顶无法判定的叶子节点：只沿着Unknown的节点往下找
+*/
+func (node *Node) traverseTreeToFindUnknownRule(ids []int) []int {
+	if !node.Unknown {
+		return ids
+	}
+	if node.Leaf {
+		if ruleID, err := strconv.Atoi(node.Expr); err == nil {
+			ids = append(ids, ruleID)
+		}
+		return ids
+	}
+	for _, child := range node.Children {
+		ids = child.traverseTreeToFindUnknownRule(ids)
+	}
+	return ids
+}
+
+/**
+  去掉neutral中的叶子节点后的逻辑表达式：and、or中去掉该操作数，not及所有操作数都被去掉的组整体去掉，都去掉时返回空字符串
+*/
+func (node *Node) pruneLogic(neutral map[int]bool) string {
+	if node.Leaf {
+		if ruleID, err := strconv.Atoi(node.Expr); err == nil && neutral[ruleID] {
+			return EmptyStr
+		}
+		return node.Expr
+	}
+	if len(node.Children) == 0 {
+		// 多余的括号，如 ( ( 1 ) )
+		expr := strings.TrimSpace(node.Expr)
+		if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+			if inner := logicToTree(strings.TrimSpace(expr[1 : len(expr)-1])); inner != nil {
+				return inner.pruneLogic(neutral)
+			}
+		}
+		return node.Expr
+	}
+	var exprs []string
+	for _, child := range node.Children {
+		expr := child.pruneLogic(neutral)
+		if expr == EmptyStr {
+			continue
+		}
+		if strings.Contains(expr, Space) {
+			expr = "( " + expr + " )"
+		}
+		exprs = append(exprs, expr)
+	}
+	switch {
+	case len(exprs) == 0:
+		return EmptyStr
+	case node.ChildrenOp == string(OperatorNot):
+		return "not " + exprs[0]
+	case len(exprs) == 1:
+		return strings.TrimSuffix(strings.TrimPrefix(exprs[0], "( "), " )")
+	default:
+		return strings.Join(exprs, Space+node.ChildrenOp+Space)
+	}
+}
+
+/**
+  获取导致树顶false的所有叶子节点：沿着值与期望不符的节点往下找，不区分责任
+*/
+func (node *Node) traverseTreeToFindAllFailRules(ids []int) []int {
+	if !node.Computed || node.Unknown || node.Should == node.Val {
+		return ids
+	}
+	if node.Leaf {
+		if ruleID, err := strconv.Atoi(node.Expr); err == nil {
+			ids = append(ids, ruleID)
+		}
+		return ids
+	}
+	for _, child := range node.Children {
+		ids = child.traverseTreeToFindAllFailRules(ids)
+	}
+	return ids
+}
+
 /**
   层序遍历获取导致树顶false的叶子节点
 */
@@ -133,7 +242,7 @@ func (node *Node) traverseTreeInLayerToFindFailRule(ids []int) ([]int, error) {
 			if buf[i].Children != nil {
 				if buf[i].isFailNode() {
 					// 找到了导致失败的非叶子节点，遍历它即可，所以要清空它后面的所有节点
-					buf = append(buf[:i+1])
+					buf = buf[:i+1]
 				}
 				buf = append(buf, buf[i].Children...)
 			}
@@ -176,11 +285,11 @@ func (node *Node) traverseTreeInLayerToFindSuccessRule(ids []int) ([]int, error)
 }
 
 func (node *Node) isFailNode() bool {
-	return node.Blamed && node.Computed && node.Should != node.Val
+	return node.Blamed && node.Computed && !node.Unknown && node.Should != node.Val
 }
 
 func (node *Node) isSuccessNode() bool {
-	return node.Computed && node.Should == node.Val
+	return node.Computed && !node.Unknown && node.Should == node.Val
 }
 
 func propagateTree(head *Node) {
@@ -303,7 +412,7 @@ func replaceBiggestBracketContentAtOnce(expr string, mapReplace map[string]strin
 			bracketStack = append(bracketStack, v)
 		} else if v == ')' {
 			// delete last ')'
-			bracketStack = append(bracketStack[:len(bracketStack)-1])
+			bracketStack = bracketStack[:len(bracketStack)-1]
 			if len(bracketStack) == 0 {
 				// it's one biggest (***)block, break to replace
 				break
@@ -95,3 +95,44 @@ func TestLogicToTree3(t *testing.T) {
 	traverseTreeInPostOrder(head)
 	assert.NotNil(t, head)
 }
+
+func TestLogicToTreeKleene(t *testing.T) {
+	logic := "1 and ( 2 or not 3 )"
+	values := map[int]Truth{1: TruthTrue, 2: TruthUnknown, 3: TruthTrue}
+	head := logicToTree(logic)
+	err := head.traverseTreeInPostOrderForCalculateTri(values)
+	assert.Nil(t, err)
+	assert.Equal(t, TruthUnknown, head.truth())
+	assert.Equal(t, []int{2}, head.traverseTreeToFindUnknownRule(nil))
+
+	// reverse polish notation agrees with tree
+	r := &Rules{}
+	result, err := r.calculateExpressionTri(logic, values)
+	assert.Nil(t, err)
+	assert.Equal(t, TruthUnknown, result)
+
+	values[1] = TruthFalse
+	result, err = r.calculateExpressionTri(logic, values)
+	assert.Nil(t, err)
+	assert.Equal(t, TruthFalse, result)
+}
+
+func TestNode_PruneLogic(t *testing.T) {
+	neutral := map[int]bool{1: true, 4: true}
+	cases := map[string]string{
+		"1 or 2":                     "2",
+		"1 and 2":                    "2",
+		"not 1 and 2":                "2",
+		"2 or not 3":                 "2 or ( not 3 )",
+		"1 or 4":                     "",
+		"( 1 or 4 ) and 2":           "2",
+		"( 1 and 2 ) or ( 3 and 4 )": "2 or 3",
+		"( 1 or 2 or 3 ) and not 5":  "( 2 or 3 ) and ( not 5 )",
+		"not ( 1 and ( 2 or 3 ) )":   "not ( 2 or 3 )",
+		"( ( 1 ) ) and 2":            "2",
+		"( ( 2 ) ) and 1":            "2",
+	}
+	for logic, expect := range cases {
+		assert.Equal(t, expect, logicToTree(logic).pruneLogic(neutral), logic)
+	}
+}
@@ -0,0 +1,256 @@
+package ruler
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+// TypedRules 绑定Go类型的Rules，构造时已校验每条子规则的key和算符
+type TypedRules[T any] struct {
+	rules *Rules
+}
+
+// Compile 用json串构造绑定类型T的规则，key必须是T中存在的字段路径，算符须与字段类型相容
+func Compile[T any](jsonStr []byte, logic string) (*TypedRules[T], error) {
+	return CompileWithAdapter[T](jsonStr, logic, nil)
+}
+
+// CompileWithAdapter 同Compile，字段键名按adapter解析
+func CompileWithAdapter[T any](jsonStr []byte, logic string, adapter *StructAdapter) (*TypedRules[T], error) {
+	rulesObj, err := newRulesWithJSON(jsonStr)
+	if err != nil {
+		return nil, err
+	}
+	return CompileArray[T](rulesObj.Rules, logic, adapter)
+}
+
+// CompileArray 用rule数组构造绑定类型T的规则
+func CompileArray[T any](rules []*Rule, logic string, adapter *StructAdapter) (*TypedRules[T], error) {
+	rulesObj, err := NewRulesWithArrayAndLogic(rules, logic)
+	if err != nil {
+		return nil, err
+	}
+	rulesObj.Adapter = adapter
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for _, rule := range rulesObj.Rules {
+		if err := adapter.checkRule(t, rule); err != nil {
+			return nil, err
+		}
+	}
+	return &TypedRules[T]{rules: rulesObj}, nil
+}
+
+// Rules 返回底层的Rules
+func (trs *TypedRules[T]) Rules() *Rules {
+	return trs.rules
+}
+
+// Fit 匹配T类型的值
+func (trs *TypedRules[T]) Fit(v T) (bool, map[int]string) {
+	return trs.rules.Fit(v)
+}
+
+// FitAskVal 匹配T类型的值，同时返回所有子规则key值
+func (trs *TypedRules[T]) FitAskVal(v T) (bool, map[int]string, map[int]interface{}) {
+	return trs.rules.FitAskVal(v)
+}
+
+// checkRule 校验子规则的key能在t中找到，且算符、值与字段类型相容
+func (a *StructAdapter) checkRule(t reflect.Type, rule *Rule) error {
+	if a == nil {
+		a = defaultAdapter
+	}
+	if keys := rule.keyPaths(); len(keys) == 2 {
+		// 纬度、经度两个key须是数字或字符串
+		for _, key := range keys {
+			ft, err := a.typeOfPath(t, key)
+			if err != nil {
+				return fmt.Errorf("rule %d: key %q: %s", rule.ID, key, err.Error())
+			}
+			if ft.Kind() != reflect.Interface && ft.Kind() != reflect.String && !isNumberKind(ft.Kind()) {
+				return fmt.Errorf("rule %d: key %q: operator %q not supported on %s", rule.ID, key, rule.Op, ft)
+			}
+		}
+		return nil
+	}
+	ft, err := a.typeOfPath(t, rule.Key)
+	if err != nil {
+		return fmt.Errorf("rule %d: key %q: %s", rule.ID, rule.Key, err.Error())
+	}
+	if err = checkOperand(ft, rule); err != nil {
+		return fmt.Errorf("rule %d: key %q: %s", rule.ID, rule.Key, err.Error())
+	}
+	return nil
+}
+
+// typeOfPath 按key路径静态解析出字段类型，遇到interface{}时无法继续检查，直接返回
+func (a *StructAdapter) typeOfPath(t reflect.Type, key string) (reflect.Type, error) {
+	paths, err := parseKeyPath(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range paths {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Interface:
+			return t, nil
+		case reflect.Struct:
+			field, ft, ok := a.lookupField(t, step)
+			if !ok {
+				return nil, fmt.Errorf("no field %q in %s", step, t)
+			}
+			if a.legacy && field.has("string") {
+				ft = reflect.TypeOf(EmptyStr)
+			}
+			t = ft
+		case reflect.Map:
+			if t.Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("can not index %s with %q", t, step)
+			}
+			t = t.Elem()
+		default:
+			return nil, fmt.Errorf("can not find %q in %s", step, t)
+		}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, nil
+}
+
+// lookupField 在结构体类型中按键名查找字段，包括平铺的嵌入结构体与flatten字段
+func (a *StructAdapter) lookupField(t reflect.Type, name string) (*structField, reflect.Type, bool) {
+	fields := a.fieldsOf(t)
+	for _, field := range fields {
+		if !field.embedded && field.name == name {
+			return field, t.Field(field.index).Type, true
+		}
+	}
+	for _, field := range fields {
+		if !field.embedded && !(a.legacy && field.has("flatten")) {
+			continue
+		}
+		ft := t.Field(field.index).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct {
+			continue
+		}
+		if sub, subType, ok := a.lookupField(ft, name); ok {
+			return sub, subType, true
+		}
+	}
+	return nil, nil, false
+}
+
+// checkOperand 检查算符与字段类型、规则值是否相容
+func checkOperand(t reflect.Type, rule *Rule) error {
+	if t.Kind() == reflect.Interface {
+		// 运行时才知道类型
+		return nil
+	}
+	isNum := isNumberKind(t.Kind())
+	isStr := t.Kind() == reflect.String
+	_, isRuleStr := rule.Val.(string)
+	isRuleNum := rule.Val != nil && isNumberKind(reflect.TypeOf(rule.Val).Kind())
+	switch rule.Op {
+	case "=", "eq", ">", "gt", "<", "lt", ">=", "gte", "<=", "lte", "!=", "neq":
+		if isNum && isRuleNum || isStr && isRuleStr {
+			return nil
+		}
+		if !isNum && !isStr {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		return fmt.Errorf("operator %q: value %v does not match %s", rule.Op, rule.Val, t)
+	case "@", "in", "!@", "nin":
+		if !isNum && !isStr && t.Kind() != reflect.Bool {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		if !isRuleStr && !isList(rule.Val) {
+			return fmt.Errorf("operator %q: value should be a list", rule.Op)
+		}
+		return nil
+	case "^$", "regex":
+		if !isStr {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		if !isRuleStr {
+			return fmt.Errorf("operator %q: value should be a pattern string", rule.Op)
+		}
+		if _, err := regexp.Compile(rule.Val.(string)); err != nil {
+			return fmt.Errorf("operator %q: %s", rule.Op, err.Error())
+		}
+		return nil
+	case "<<", "between":
+		if !isNum && !isStr && t != reflect.TypeOf(time.Time{}) {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		if !isRuleStr {
+			return fmt.Errorf("operator %q: value should be an interval string", rule.Op)
+		}
+		set, err := parseIntervals(rule.Val.(string))
+		if err != nil {
+			return fmt.Errorf("operator %q: %s", rule.Op, err.Error())
+		}
+		// 数字字段不能用日期区间，时间字段不能用数字区间，字符串字段两者皆可
+		if !isStr && set[0].isDate == isNum {
+			return fmt.Errorf("operator %q: interval %q does not match %s", rule.Op, rule.Val, t)
+		}
+		return nil
+	case "@@", "intersect", "subset", "superset":
+		if !isStr && t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		if !isRuleStr && !isList(rule.Val) {
+			return fmt.Errorf("operator %q: value should be a list", rule.Op)
+		}
+		return nil
+	case "ipin", "ipnin":
+		if !isStr && t != reflect.TypeOf(net.IP{}) && t != reflect.TypeOf(netip.Addr{}) {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		return nil
+	case "ver=", "ver>", "ver>=", "ver<", "ver<=", "verbetween", "semver":
+		if !isStr {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		return nil
+	case "georadius", "geopolygon":
+		if isNum || isStr || t.Kind() == reflect.Bool {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		return nil
+	case "like", "nlike", "ilike", "nilike", "glob", "iglob", "levenshtein", "damerau", "jarowinkler":
+		if !isStr {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		return nil
+	case "format":
+		if !isStr && !(isNum && t.Kind() != reflect.Float32 && t.Kind() != reflect.Float64) {
+			return fmt.Errorf("operator %q not supported on %s", rule.Op, t)
+		}
+		return nil
+	case "0", "empty", "1", "nempty", "exists", "notexists", "null", "notnull", "blank", "notblank":
+		return nil
+	default:
+		return fmt.Errorf("unknown operator %q", rule.Op)
+	}
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,73 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedExams struct {
+	Math   int
+	Physic int
+}
+
+type typedStudent struct {
+	Name  string
+	Grade int
+	Sex   string
+	Score *typedExams
+	Extra map[string]interface{}
+}
+
+func TestCompile(t *testing.T) {
+	jsonRules := []byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match"},
+	{"op": "=", "key": "Sex", "val": "male", "id": 2, "msg": "not male"},
+	{"op": ">=", "key": "Score.Math", "val": 90, "id": 3, "msg": "Math not so well"},
+	{"op": "between", "key": "Score.Physic", "val": "[90, ]", "id": 4, "msg": "Physic not so well"},
+	{"op": "regex", "key": "Extra.Nick.Any", "val": "^c", "id": 5}
+	]`)
+	trs, err := Compile[*typedStudent](jsonRules, "1 and not 2 and (3 or 4)")
+	assert.Nil(t, err)
+
+	fit, msg := trs.Fit(&typedStudent{Grade: 4, Sex: "female", Score: &typedExams{Math: 96, Physic: 93}})
+	assert.False(t, fit)
+	assert.Equal(t, map[int]string{1: "Grade not match"}, msg)
+}
+
+func TestCompile_Errors(t *testing.T) {
+	cases := map[string]string{
+		`[{"op": "=", "key": "Score.Maths", "val": 3, "id": 7}]`:               `rule 7: key "Score.Maths": no field "Maths"`,
+		`[{"op": "between", "key": "Score", "val": "[1,2]", "id": 3}]`:         `rule 3: key "Score": operator "between" not supported on ruler.typedExams`,
+		`[{"op": "between", "key": "Grade", "val": "[2024-01-01,]", "id": 9}]`: `rule 9: key "Grade": operator "between": interval "[2024-01-01,]" does not match int`,
+		`[{"op": "between", "key": "Grade", "val": "[3,1]", "id": 10}]`:        `rule 10: `,
+		`[{"op": "regex", "key": "Grade", "val": "^1", "id": 4}]`:              `rule 4: key "Grade": operator "regex" not supported on int`,
+		`[{"op": "=", "key": "Grade", "val": "3", "id": 5}]`:                   `rule 5: key "Grade": operator "=": value 3 does not match int`,
+		`[{"op": "~", "key": "Grade", "val": 3, "id": 6}]`:                     `rule 6: key "Grade": unknown operator "~"`,
+		`[{"op": "=", "key": "Grade.Deep", "val": 3, "id": 8}]`:                `rule 8: key "Grade.Deep": can not find "Deep" in int`,
+	}
+	for jsonRules, expect := range cases {
+		_, err := Compile[typedStudent]([]byte(jsonRules), "")
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), expect)
+		}
+	}
+}
+
+func TestCompileWithAdapter(t *testing.T) {
+	type Exams struct {
+		Math int `json:"math"`
+	}
+	type Student struct {
+		Exams `json:"score"`
+		Name  string `json:"name"`
+	}
+	jsonRules := []byte(`[{"op": ">=", "key": "score.math", "val": 90, "id": 1}]`)
+	trs, err := CompileWithAdapter[Student](jsonRules, "", NewStructAdapter("json"))
+	assert.Nil(t, err)
+	fit, _ := trs.Fit(Student{Exams: Exams{Math: 91}})
+	assert.True(t, fit)
+
+	_, err = CompileWithAdapter[Student]([]byte(`[{"op": "=", "key": "Name", "val": "a"}]`), "", NewStructAdapter("json"))
+	assert.NotNil(t, err)
+}
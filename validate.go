@@ -0,0 +1,89 @@
+package ruler
+
+/**
+  表单校验：把不满足的子规则按key归类，直接用作接口的400响应，如
+  {"Grade": ["Grade not match"], "Score.Math": ["Math score 85 is below 90"]}
+  1. 返回所有导致fit为false的子规则，而不只是Fit提示中的第一个
+  2. or中所有不满足的分支都会返回，not下则返回满足了的子规则
+  3. warning、info级别的子规则不参与校验，与FitWarn一样从逻辑表达式中去掉，or中也不能替代其他分支
+  4. 同一key的提示按子规则顺序排列并去重，没有提示的子规则使用DefaultValidateMsg
+*/
+
+// DefaultValidateMsg 子规则没有提示时使用的校验提示
+const DefaultValidateMsg = "invalid value"
+
+// Validate Rules校验结构体，fields为不满足的子规则key到提示的映射，校验通过时为nil
+func (rs *Rules) Validate(o interface{}) (bool, map[string][]string) {
+	return rs.validate(rs.structGetter(rs.Adapter, o), EmptyStr)
+}
+
+// ValidateWithMap Rules校验map
+func (rs *Rules) ValidateWithMap(o map[string]interface{}) (bool, map[string][]string) {
+	return rs.validate(mapGetter(o), EmptyStr)
+}
+
+// ValidateLocale Rules校验结构体，提示使用指定语言
+func (rs *Rules) ValidateLocale(o interface{}, locale string) (bool, map[string][]string) {
+	return rs.validate(rs.structGetter(rs.Adapter, o), locale)
+}
+
+// ValidateWithMapLocale Rules校验map，提示使用指定语言
+func (rs *Rules) ValidateWithMapLocale(o map[string]interface{}, locale string) (bool, map[string][]string) {
+	return rs.validate(mapGetter(o), locale)
+}
+
+func (rs *Rules) validate(get valueGetter, locale string) (bool, map[string][]string) {
+	results := make(map[int]Truth)
+	answer, _, values, _ := rs.judge(get, false, locale, make(map[int]string), results)
+	if answer == TruthTrue {
+		return true, nil
+	}
+	failed := make(map[int]bool)
+	for _, id := range rs.failedRuleIDs(results) {
+		failed[id] = true
+	}
+	fields := make(map[string][]string)
+	for _, rule := range rs.Rules {
+		if !failed[rule.ID] {
+			continue
+		}
+		msg := rs.tipIn(rule, values[rule.ID], locale, false)
+		if msg == EmptyStr {
+			msg = DefaultValidateMsg
+		}
+		if !containStr(fields[rule.Key], msg) {
+			fields[rule.Key] = append(fields[rule.Key], msg)
+		}
+	}
+	return false, fields
+}
+
+// failedRuleIDs 导致fit为false的所有子规则，逻辑表达式为空时即所有不满足的子规则；warning、info级别的子规则不参与，见blockingLogic
+func (rs *Rules) failedRuleIDs(results map[int]Truth) []int {
+	var ids []int
+	if rs.Logic == EmptyStr {
+		for _, rule := range rs.Rules {
+			if results[rule.ID] == TruthFalse && rule.blocking() {
+				ids = append(ids, rule.ID)
+			}
+		}
+		return ids
+	}
+	head := logicToTree(rs.blockingLogic())
+	if head == nil {
+		return nil
+	}
+	if err := head.traverseTreeInPostOrderForCalculateTri(results); err != nil {
+		return nil
+	}
+	return head.traverseTreeToFindAllFailRules(ids)
+}
+
+func containStr(list []string, o string) bool {
+	for _, s := range list {
+		if s == o {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_Validate(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "nempty", "key": "Name", "id": 1, "msg": "name is required"},
+	{"op": "between", "key": "Grade", "val": "[1, 6]", "id": 2, "msg": "grade should be in [1, 6]"},
+	{"op": "=", "key": "Grade", "val": 3, "id": 3, "msg": "only grade {{.Expected}} is open"},
+	{"op": ">=", "key": "Score.Math", "val": 90, "id": 4, "msg": "Math score {{.Actual}} is below {{.Expected}}"},
+	{"op": ">=", "key": "Score.Physic", "val": 90, "id": 5, "msg": "Physic score {{.Actual}} is below {{.Expected}}"},
+	{"op": "format", "key": "Email", "val": "email", "id": 6},
+	{"op": "=", "key": "Sex", "val": "male", "id": 7},
+	{"op": "nempty", "key": "Phone", "id": 8, "msg": "phone is recommended", "severity": "warning"}
+	]`), "1 and 2 and 3 and (4 or 5) and 6 and not 7 and 8")
+	assert.Nil(t, err)
+
+	o := map[string]interface{}{
+		"Grade": 7,
+		"Score": map[string]interface{}{"Math": 85, "Physic": 80},
+		"Email": "chris",
+		"Sex":   "male",
+	}
+	// Fit只给出第一个导致失败的子规则
+	_, tips := rs.FitWithMap(o)
+	assert.Len(t, tips, 1)
+
+	ok, fields := rs.ValidateWithMap(o)
+	assert.False(t, ok)
+	assert.Equal(t, map[string][]string{
+		"Name":         {"name is required"},
+		"Grade":        {"grade should be in [1, 6]", "only grade 3 is open"},
+		"Score.Math":   {"Math score 85 is below 90"},
+		"Score.Physic": {"Physic score 80 is below 90"},
+		"Email":        {"invalid email address"},
+		"Sex":          {DefaultValidateMsg},
+	}, fields)
+
+	// or中有一个分支满足时不报错
+	o = map[string]interface{}{
+		"Name":  "Chris",
+		"Grade": 3,
+		"Score": map[string]interface{}{"Math": 95, "Physic": 80},
+		"Email": "chris@example.com",
+		"Sex":   "female",
+	}
+	ok, fields = rs.ValidateWithMap(o)
+	assert.True(t, ok)
+	assert.Nil(t, fields)
+
+	type Exams struct {
+		Math   int
+		Physic int
+	}
+	type Student struct {
+		Name  string
+		Grade int
+		Email string
+		Sex   string
+		Score *Exams
+	}
+	ok, fields = rs.Validate(&Student{Name: "Chris", Grade: 3, Email: "chris@example.com", Sex: "female", Score: &Exams{Math: 80, Physic: 95}})
+	assert.True(t, ok)
+	assert.Nil(t, fields)
+	ok, fields = rs.Validate(&Student{Name: "Chris", Grade: 3, Email: "chris@example.com", Sex: "female", Score: &Exams{Math: 80, Physic: 85}})
+	assert.False(t, ok)
+	assert.Equal(t, map[string][]string{"Score.Math": {"Math score 80 is below 90"}, "Score.Physic": {"Physic score 85 is below 90"}}, fields)
+}
+
+func TestRules_ValidateWithoutLogic(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "nempty", "key": "Name", "id": 1, "msg": "required"},
+	{"op": "nempty", "key": "Email", "id": 2, "msg": "required"},
+	{"op": "format", "key": "Email", "val": "email", "id": 3, "msg": "required"}
+	]`), "")
+	assert.Nil(t, err)
+	ok, fields := rs.ValidateWithMap(map[string]interface{}{})
+	assert.False(t, ok)
+	assert.Equal(t, map[string][]string{"Name": {"required"}, "Email": {"required"}}, fields)
+}
+
+func TestRules_ValidateWarnUnderNot(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match"},
+	{"op": "=", "key": "Sex", "val": "male", "id": 2, "msg": "is male", "severity": "warning"}
+	]`), "1 and not 2")
+	assert.Nil(t, err)
+	ok, fields := rs.ValidateWithMap(map[string]interface{}{"Grade": 3, "Sex": "female"})
+	assert.True(t, ok)
+	assert.Nil(t, fields)
+	ok, fields = rs.ValidateWithMap(map[string]interface{}{"Grade": 3, "Sex": "male"})
+	assert.True(t, ok)
+	assert.Nil(t, fields)
+	ok, fields = rs.ValidateWithMap(map[string]interface{}{"Grade": 2, "Sex": "male"})
+	assert.False(t, ok)
+	assert.Equal(t, map[string][]string{"Grade": {"Grade not match"}}, fields)
+
+	// 同一子规则需要的值相矛盾时，warning子规则仍不会出现在校验结果中
+	rs, err = NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "Grade", "val": 3, "id": 1, "msg": "Grade not match"},
+	{"op": "=", "key": "Sex", "val": "male", "id": 2, "msg": "is male", "severity": "warning"}
+	]`), "1 and 2 and not 2")
+	assert.Nil(t, err)
+	ok, fields = rs.ValidateWithMap(map[string]interface{}{"Grade": 2, "Sex": "male"})
+	assert.False(t, ok)
+	assert.Equal(t, map[string][]string{"Grade": {"Grade not match"}}, fields)
+}
+
+func TestRules_ValidateWarnUnderOr(t *testing.T) {
+	rs, err := NewRulesWithJSONAndLogic([]byte(`[
+	{"op": "=", "key": "A", "val": 1, "id": 1, "msg": "A bad", "severity": "warning"},
+	{"op": "=", "key": "B", "val": 1, "id": 2, "msg": "B bad"}
+	]`), "1 or 2")
+	assert.Nil(t, err)
+	ok, fields := rs.ValidateWithMap(map[string]interface{}{"A": 0, "B": 0})
+	assert.False(t, ok)
+	assert.Equal(t, map[string][]string{"B": {"B bad"}}, fields)
+	ok, fields = rs.ValidateWithMap(map[string]interface{}{"A": 1, "B": 0})
+	assert.False(t, ok)
+	assert.Equal(t, map[string][]string{"B": {"B bad"}}, fields)
+	ok, fields = rs.ValidateWithMap(map[string]interface{}{"A": 0, "B": 1})
+	assert.True(t, ok)
+	assert.Nil(t, fields)
+
+	// 逻辑表达式中只有warning、info级别子规则时校验通过
+	rs.Rules[1].Severity = SeverityInfo
+	ok, fields = rs.ValidateWithMap(map[string]interface{}{"A": 0, "B": 0})
+	assert.True(t, ok)
+	assert.Nil(t, fields)
+}
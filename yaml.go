@@ -0,0 +1,200 @@
+package ruler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+/**
+  yaml格式的规则文档，字段与json文档一致，可以写注释：
+  version: 1
+  kind: rules
+  logic: 1 and 2
+  rules:
+    - {op: "=", key: Grade, val: 3, id: 1}  # 三年级
+    - {op: between, key: Score.Math, val: "[90, ]", id: 2}
+  读取时的错误带行列号；UpdateYAML把规则写回原文档，保留原有的注释和风格
+*/
+
+// LoadRulesYAML 读取并校验yaml格式的Rules文档
+func LoadRulesYAML(data []byte) (*Rules, error) {
+	var doc rulesDocument
+	root, err := decodeYAML(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := doc.load()
+	if err != nil {
+		return nil, yamlError(root, err)
+	}
+	return rs, nil
+}
+
+// LoadRulesListYAML 读取并校验yaml格式的RulesList文档
+func LoadRulesListYAML(data []byte) (*RulesList, error) {
+	var doc rulesListDocument
+	root, err := decodeYAML(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	rst, err := doc.load()
+	if err != nil {
+		return nil, yamlError(root, err)
+	}
+	return rst, nil
+}
+
+// MarshalYAML Rules序列化为yaml文档
+func (rs *Rules) MarshalYAML() (interface{}, error) {
+	doc := rs.document()
+	doc.Version, doc.Kind = DocumentVersion, kindRules
+	return doc, nil
+}
+
+// MarshalYAML RulesList序列化为yaml文档
+func (rst *RulesList) MarshalYAML() (interface{}, error) {
+	doc := rulesListDocument{Version: DocumentVersion, Kind: kindRulesList, Name: rst.Name, Msg: rst.Msg}
+	doc.RulesList = make([]*rulesDocument, 0, len(rst.RulesList))
+	for _, rs := range rst.RulesList {
+		doc.RulesList = append(doc.RulesList, rs.document())
+	}
+	return doc, nil
+}
+
+// UpdateYAML 把Rules或RulesList写回原yaml文档，原文档中仍存在的字段、子规则（按id对应）保留注释和风格
+func UpdateYAML(src []byte, v interface{}) ([]byte, error) {
+	var fresh yaml.Node
+	if err := fresh.Encode(v); err != nil {
+		return nil, err
+	}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&fresh}}
+	var old yaml.Node
+	if err := yaml.Unmarshal(src, &old); err != nil {
+		return nil, fmt.Errorf("invalid rules document: %s", err.Error())
+	}
+	if old.Kind == yaml.DocumentNode && len(old.Content) == 1 {
+		doc.HeadComment, doc.FootComment = old.HeadComment, old.FootComment
+		copyYAMLStyle(old.Content[0], &fresh)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeYAML 严格解码，未知字段视为错误，同时返回节点树用于定位
+func decodeYAML(data []byte, doc interface{}) (*yaml.Node, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid rules document: %s", err.Error())
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(doc); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("invalid rules document: empty document")
+		}
+		return nil, fmt.Errorf("invalid rules document: %s", err.Error())
+	}
+	var extra yaml.Node
+	if err := dec.Decode(&extra); err != io.EOF {
+		return nil, fmt.Errorf("invalid rules document: unexpected data after document")
+	}
+	return &root, nil
+}
+
+// yamlError 校验错误加上出错位置的行列号
+func yamlError(root *yaml.Node, err error) error {
+	e, ok := err.(*documentError)
+	if !ok {
+		return err
+	}
+	node := yamlNodeAt(root, e.path)
+	if node == nil {
+		return err
+	}
+	return fmt.Errorf("yaml: line %d, column %d: %s", node.Line, node.Column, err.Error())
+}
+
+// yamlNodeAt 按路径查找节点，路径中途不存在时返回最后找到的节点
+func yamlNodeAt(root *yaml.Node, path []interface{}) *yaml.Node {
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return nil
+	}
+	node := root.Content[0]
+	for _, step := range path {
+		next := yamlChild(node, step)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node
+}
+
+func yamlChild(node *yaml.Node, step interface{}) *yaml.Node {
+	switch t := step.(type) {
+	case string:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == t {
+				return node.Content[i+1]
+			}
+		}
+	case int:
+		if node.Kind == yaml.SequenceNode && t < len(node.Content) {
+			return node.Content[t]
+		}
+	}
+	return nil
+}
+
+// copyYAMLStyle 从原节点复制注释和风格，映射按键名对应，元素为子规则的序列按id对应，其余按下标对应
+func copyYAMLStyle(from, to *yaml.Node) {
+	if from.Kind != to.Kind {
+		return
+	}
+	to.HeadComment, to.LineComment, to.FootComment = from.HeadComment, from.LineComment, from.FootComment
+	switch to.Kind {
+	case yaml.ScalarNode:
+		if from.ShortTag() == to.ShortTag() {
+			to.Style = from.Style
+		}
+	case yaml.MappingNode:
+		to.Style = from.Style
+		for i := 0; i+1 < len(to.Content); i += 2 {
+			for j := 0; j+1 < len(from.Content); j += 2 {
+				if from.Content[j].Value == to.Content[i].Value {
+					copyYAMLStyle(from.Content[j], to.Content[i])
+					copyYAMLStyle(from.Content[j+1], to.Content[i+1])
+					break
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		to.Style = from.Style
+		for i, item := range to.Content {
+			if id := yamlChild(item, "id"); id != nil {
+				for _, o := range from.Content {
+					if oid := yamlChild(o, "id"); oid != nil && oid.Value == id.Value {
+						copyYAMLStyle(o, item)
+						break
+					}
+				}
+			} else if i < len(from.Content) {
+				copyYAMLStyle(from.Content[i], item)
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package ruler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const studentYAML = `# 好学生
+version: 1
+kind: rules
+name: student
+logic: 1 and (2 or 3)
+val:
+  level: 3
+rules:
+  - {op: "=", key: Grade, val: 3, id: 1, msg: Grade not match} # 三年级
+  - op: in
+    key: Sex
+    val: [male, female]
+    id: 2
+  # 数学90分以上
+  - {op: between, key: Score.Math, val: "[90, ]", id: 3}
+`
+
+func TestLoadRulesYAML(t *testing.T) {
+	rs, err := LoadRulesYAML([]byte(studentYAML))
+	assert.Nil(t, err)
+	assert.Equal(t, "student", rs.Name)
+	assert.Equal(t, map[string]interface{}{"level": 3}, rs.Val)
+	assert.Equal(t, []interface{}{"male", "female"}, rs.Rules[1].Val)
+	fit, _ := rs.FitWithMap(map[string]interface{}{"Grade": 3, "Sex": "female"})
+	assert.True(t, fit)
+
+	// yaml与json文档可互相转换
+	data, err := yaml.Marshal(rs)
+	assert.Nil(t, err)
+	again, err := LoadRulesYAML(data)
+	assert.Nil(t, err)
+	assert.Equal(t, rs.Logic, again.Logic)
+	assert.Equal(t, rs.Rules, again.Rules)
+}
+
+func TestLoadRulesYAML_Errors(t *testing.T) {
+	cases := map[string]string{
+		"version: 1\nkind: rules\nrules:\n  - {op: '=', key: A, val: 1, id: 1}\n  - {op: between, key: A, val: '[3, 1]', id: 2}\n": "yaml: line 5, column 5: rule 2: interval",
+		"version: 1\nkind: rules\nlogic: 1 or 3\nrules:\n  - {op: '=', key: A, val: 1, id: 1}\n":                                   "yaml: line 3, column 8: invalid logic expression",
+		"version: 1\nkind: rulesList\nrules:\n  - {op: '=', key: A, val: 1, id: 1}\n":                                              `yaml: line 2, column 7: invalid rules document: kind should be "rules"`,
+		"kind: rules\nrules:\n  - {op: '=', key: A, val: 1, id: 1}\n":                                                              "yaml: line 1, column 1: invalid rules document: missing version",
+		"version: 1\nkind: rules\nrules:\n  - {op: '=', key: A, val: 1, id: 1, note: x}\n":                                         "line 4: field note not found",
+		"version: 1\nkind: rules\nrules:\n  - {op: '=', key: A, val: 1, id: x}\n":                                                  "line 4: cannot unmarshal",
+		"version: 1\nkind: rules\nrules: [\n":                                                                                      "invalid rules document: yaml: line",
+		"":                                                                                                                         "empty document",
+		"version: 1\nkind: rules\nrules:\n  - {op: '=', key: A, val: 1, id: 1}\n---\nversion: 1\n":                                 "unexpected data",
+	}
+	for doc, expect := range cases {
+		_, err := LoadRulesYAML([]byte(doc))
+		if assert.NotNil(t, err, doc) {
+			assert.Contains(t, err.Error(), expect, doc)
+		}
+	}
+}
+
+func TestLoadRulesListYAML(t *testing.T) {
+	doc := `version: 1
+kind: rulesList
+rulesList:
+  - name: gold
+    logic: 1 and 2
+    rules:
+      - {op: ">=", key: Score, val: 90, id: 1}
+      - {op: "=", key: Vip, val: 1, id: 2}
+  - rules:
+      - {op: ">=", key: Score, val: 60, id: 1}
+      - {op: between, key: Name, val: "[3, 1]", id: 2}
+`
+	_, err := LoadRulesListYAML([]byte(doc))
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "yaml: line 11, column 9: rulesList[1]: rule 2:"), err.Error())
+	}
+
+	rst, err := LoadRulesListYAML([]byte(strings.Replace(doc, `"[3, 1]"`, `"[1, 3]"`, 1)))
+	assert.Nil(t, err)
+	assert.Equal(t, "gold", rst.RulesList[0].Name)
+	assert.Equal(t, EmptyStr, rst.RulesList[1].Name)
+	data, err := yaml.Marshal(rst)
+	assert.Nil(t, err)
+	again, err := LoadRulesListYAML(data)
+	assert.Nil(t, err)
+	assert.Equal(t, rst.RulesList[0].Rules, again.RulesList[0].Rules)
+}
+
+func TestUpdateYAML(t *testing.T) {
+	rs, err := LoadRulesYAML([]byte(studentYAML))
+	assert.Nil(t, err)
+	// 删掉第2条规则，修改第3条的值
+	rs.Rules = []*Rule{rs.Rules[0], rs.Rules[2]}
+	rs.Rules[1].Val = "[95, ]"
+	rs, err = NewRulesWithArrayAndLogicAndInfo(rs.Rules, "1 and 3", map[string]string{"name": rs.Name})
+	assert.Nil(t, err)
+
+	data, err := UpdateYAML([]byte(studentYAML), rs)
+	assert.Nil(t, err)
+	out := string(data)
+	assert.Contains(t, out, "# 好学生")
+	assert.Contains(t, out, "# 三年级")
+	assert.Contains(t, out, "# 数学90分以上")
+	assert.Contains(t, out, `{op: between, key: Score.Math, val: "[95, ]", id: 3}`)
+	assert.NotContains(t, out, "Sex")
+
+	again, err := LoadRulesYAML(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "1 and 3", again.Logic)
+	assert.Equal(t, "[95, ]", again.Rules[1].Val)
+
+	_, err = UpdateYAML([]byte("rules: [\n"), rs)
+	assert.NotNil(t, err)
+}